@@ -3,6 +3,7 @@ package client
 
 import (
 	"context"
+	"encoding/json"
 
 	"github.com/shaneholloman/mcp-server-go/mcp"
 )
@@ -81,4 +82,20 @@ type MCPClient interface {
 
 	// OnNotification registers a handler for notifications
 	OnNotification(handler func(notification mcp.JSONRPCNotification))
+
+	// SetSamplingHandler registers a handler for sampling requests from the server
+	SetSamplingHandler(handler SamplingHandler)
+
+	// SetRootsProvider registers a provider for roots requests from the server
+	SetRootsProvider(provider RootsProvider)
+
+	// SetRoots exposes a fixed list of roots and notifies the server of the change
+	SetRoots(ctx context.Context, roots []mcp.Root) error
+}
+
+// response is the server's answer to a request sent by a client: either its
+// raw result or the *mcp.Error the server replied with
+type response struct {
+	result *json.RawMessage
+	err    error
 }
@@ -0,0 +1,184 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/shaneholloman/mcp-server-go/mcp"
+)
+
+// serverRequestHandlers holds the handlers a client registered for requests
+// initiated by the server
+type serverRequestHandlers struct {
+	sampling SamplingHandler
+	roots    RootsProvider
+}
+
+// serverRequests tracks the requests from the server that are being handled,
+// so that they can be cancelled when the server sends notifications/cancelled
+type serverRequests struct {
+	mu       sync.Mutex
+	inFlight map[string]*serverRequest
+}
+
+// serverRequest is a request from the server whose handler is still running
+type serverRequest struct {
+	cancel    context.CancelFunc
+	cancelled bool
+}
+
+// requestKey returns the key a request ID is tracked under, so that the IDs
+// of requests and of cancellations match whatever their JSON encoding
+func requestKey(id interface{}) (string, bool) {
+	if raw, ok := id.(json.RawMessage); ok {
+		if err := json.Unmarshal(raw, &id); err != nil {
+			return "", false
+		}
+	}
+	key, err := json.Marshal(id)
+	if err != nil {
+		return "", false
+	}
+	return string(key), true
+}
+
+// start registers a request from the server as in flight and returns the
+// context to handle it with. The returned function must be called once the
+// request has been handled; it reports whether the request was cancelled,
+// in which case no response may be sent.
+func (r *serverRequests) start(id json.RawMessage) (context.Context, func() bool) {
+	ctx, cancel := context.WithCancel(context.Background())
+	key, ok := requestKey(id)
+	if !ok {
+		return ctx, func() bool {
+			cancel()
+			return false
+		}
+	}
+
+	request := &serverRequest{cancel: cancel}
+	r.mu.Lock()
+	if r.inFlight == nil {
+		r.inFlight = make(map[string]*serverRequest)
+	}
+	r.inFlight[key] = request
+	r.mu.Unlock()
+
+	return ctx, func() bool {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.inFlight[key] == request {
+			delete(r.inFlight, key)
+		}
+		cancel()
+		return request.cancelled
+	}
+}
+
+// cancel cancels the request named by a notifications/cancelled
+// notification. Unknown or already completed requests are ignored.
+func (r *serverRequests) cancel(notification mcp.JSONRPCNotification) {
+	key, ok := requestKey(notification.Params.AdditionalFields["requestId"])
+	if !ok {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if request, ok := r.inFlight[key]; ok {
+		request.cancelled = true
+		request.cancel()
+	}
+}
+
+// cancelAll cancels every request still being handled, e.g. when the client
+// is closed
+func (r *serverRequests) cancelAll() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, request := range r.inFlight {
+		request.cancelled = true
+		request.cancel()
+	}
+}
+
+// handleServerRequest answers a request the server sent to the client and
+// returns the response to write back
+func handleServerRequest(
+	ctx context.Context,
+	handlers serverRequestHandlers,
+	id json.RawMessage,
+	method string,
+	message []byte,
+) mcp.JSONRPCMessage {
+	switch method {
+	case "ping":
+		return newResponse(id, mcp.EmptyResult{})
+	case "roots/list":
+		if handlers.roots == nil {
+			return newErrorResponse(
+				id,
+				mcp.METHOD_NOT_FOUND,
+				"Roots not supported",
+			)
+		}
+		roots, err := handlers.roots.ListRoots(ctx)
+		if err != nil {
+			return mcp.NewJSONRPCErrorFromError(id, err)
+		}
+		if roots == nil {
+			roots = []mcp.Root{}
+		}
+		return newResponse(id, mcp.ListRootsResult{Roots: roots})
+	case "sampling/createMessage":
+		if handlers.sampling == nil {
+			return newErrorResponse(
+				id,
+				mcp.METHOD_NOT_FOUND,
+				"Sampling not supported",
+			)
+		}
+		var request mcp.CreateMessageRequest
+		if err := json.Unmarshal(message, &request); err != nil {
+			return newErrorResponse(
+				id,
+				mcp.INVALID_PARAMS,
+				"Invalid create message request",
+			)
+		}
+		result, err := handlers.sampling.CreateMessage(ctx, request)
+		if err != nil {
+			return mcp.NewJSONRPCErrorFromError(id, err)
+		}
+		return newResponse(id, result)
+	default:
+		return newErrorResponse(
+			id,
+			mcp.METHOD_NOT_FOUND,
+			fmt.Sprintf("Method %s not found", method),
+		)
+	}
+}
+
+func newResponse(id json.RawMessage, result interface{}) mcp.JSONRPCMessage {
+	return mcp.JSONRPCResponse{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		ID:      id,
+		Result:  result,
+	}
+}
+
+func newErrorResponse(
+	id json.RawMessage,
+	code int,
+	message string,
+) mcp.JSONRPCMessage {
+	response := mcp.JSONRPCError{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		ID:      id,
+	}
+	response.Error.Code = code
+	response.Error.Message = message
+	return response
+}
@@ -0,0 +1,137 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/shaneholloman/mcp-server-go/mcp"
+)
+
+func TestHandleServerRequest(t *testing.T) {
+	id := json.RawMessage(`"req-1"`)
+
+	tests := []struct {
+		name         string
+		handlers     serverRequestHandlers
+		method       string
+		message      string
+		expectedCode int
+	}{
+		{
+			name:    "Ping",
+			method:  "ping",
+			message: `{"jsonrpc": "2.0", "id": "req-1", "method": "ping"}`,
+		},
+		{
+			name:     "Sampling",
+			handlers: serverRequestHandlers{sampling: echoSampler},
+			method:   "sampling/createMessage",
+			message: `{
+                "jsonrpc": "2.0",
+                "id": "req-1",
+                "method": "sampling/createMessage",
+                "params": {
+                    "messages": [{"role": "user", "content": {"type": "text", "text": "hi"}}],
+                    "maxTokens": 10
+                }
+            }`,
+		},
+		{
+			name:         "Sampling without handler",
+			method:       "sampling/createMessage",
+			message:      `{"jsonrpc": "2.0", "id": "req-1", "method": "sampling/createMessage", "params": {}}`,
+			expectedCode: mcp.METHOD_NOT_FOUND,
+		},
+		{
+			name:         "Sampling handler error",
+			handlers:     serverRequestHandlers{sampling: echoSampler},
+			method:       "sampling/createMessage",
+			message:      `{"jsonrpc": "2.0", "id": "req-1", "method": "sampling/createMessage", "params": {"messages": []}}`,
+			expectedCode: mcp.INTERNAL_ERROR,
+		},
+		{
+			name: "Roots",
+			handlers: serverRequestHandlers{
+				roots: staticRoots{{URI: "file:///workspace"}},
+			},
+			method:  "roots/list",
+			message: `{"jsonrpc": "2.0", "id": "req-1", "method": "roots/list"}`,
+		},
+		{
+			name:         "Roots without provider",
+			method:       "roots/list",
+			message:      `{"jsonrpc": "2.0", "id": "req-1", "method": "roots/list"}`,
+			expectedCode: mcp.METHOD_NOT_FOUND,
+		},
+		{
+			name: "Roots provider error",
+			handlers: serverRequestHandlers{
+				roots: RootsProviderFunc(func(ctx context.Context) ([]mcp.Root, error) {
+					return nil, fmt.Errorf("roots unavailable")
+				}),
+			},
+			method:       "roots/list",
+			message:      `{"jsonrpc": "2.0", "id": "req-1", "method": "roots/list"}`,
+			expectedCode: mcp.INTERNAL_ERROR,
+		},
+		{
+			name: "Roots provider mcp.Error",
+			handlers: serverRequestHandlers{
+				roots: RootsProviderFunc(func(ctx context.Context) ([]mcp.Root, error) {
+					return nil, fmt.Errorf(
+						"listing roots: %w",
+						mcp.NewError(mcp.INVALID_REQUEST, "no workspace open", nil),
+					)
+				}),
+			},
+			method:       "roots/list",
+			message:      `{"jsonrpc": "2.0", "id": "req-1", "method": "roots/list"}`,
+			expectedCode: mcp.INVALID_REQUEST,
+		},
+		{
+			name:         "Unknown method",
+			method:       "roots/unknown",
+			message:      `{"jsonrpc": "2.0", "id": "req-1", "method": "roots/unknown"}`,
+			expectedCode: mcp.METHOD_NOT_FOUND,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := handleServerRequest(
+				context.Background(),
+				tt.handlers,
+				id,
+				tt.method,
+				[]byte(tt.message),
+			)
+
+			encoded, err := json.Marshal(response)
+			if err != nil {
+				t.Fatalf("Failed to marshal response: %v", err)
+			}
+			var decoded struct {
+				ID    string              `json:"id"`
+				Error *struct{ Code int } `json:"error"`
+			}
+			if err := json.Unmarshal(encoded, &decoded); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+
+			if decoded.ID != "req-1" {
+				t.Errorf("Expected id req-1, got %q", decoded.ID)
+			}
+			if tt.expectedCode == 0 {
+				if decoded.Error != nil {
+					t.Errorf("Unexpected error: %s", encoded)
+				}
+				return
+			}
+			if decoded.Error == nil || decoded.Error.Code != tt.expectedCode {
+				t.Errorf("Expected error code %d, got %s", tt.expectedCode, encoded)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package client
+
+import (
+	"context"
+
+	"github.com/shaneholloman/mcp-server-go/mcp"
+)
+
+// RootsProvider answers roots/list requests from the server with the
+// directories and files the client exposes to it. Registering one makes the
+// client advertise the roots capability when it initializes.
+type RootsProvider interface {
+	ListRoots(ctx context.Context) ([]mcp.Root, error)
+}
+
+// RootsProviderFunc is an adapter to use an ordinary function as a
+// RootsProvider
+type RootsProviderFunc func(ctx context.Context) ([]mcp.Root, error)
+
+// ListRoots calls f(ctx)
+func (f RootsProviderFunc) ListRoots(ctx context.Context) ([]mcp.Root, error) {
+	return f(ctx)
+}
+
+// staticRoots is the RootsProvider installed by SetRoots
+type staticRoots []mcp.Root
+
+// ListRoots returns a copy of the roots
+func (r staticRoots) ListRoots(ctx context.Context) ([]mcp.Root, error) {
+	return append([]mcp.Root{}, r...), nil
+}
+
+// rootsListChangedNotification tells the server to fetch the roots again
+var rootsListChangedNotification = mcp.JSONRPCNotification{
+	JSONRPC: mcp.JSONRPC_VERSION,
+	Notification: mcp.Notification{
+		Method: "notifications/roots/list_changed",
+	},
+}
@@ -0,0 +1,90 @@
+package client
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shaneholloman/mcp-server-go/mcp"
+	"github.com/shaneholloman/mcp-server-go/server"
+)
+
+func TestSSEMCPClient_Roots(t *testing.T) {
+	mcpServer := server.NewMCPServer("test-server", "1.0.0")
+	mcpServer.AddTool(
+		mcp.NewTool("roots"),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			roots, err := server.ServerFromContext(ctx).ListRoots(ctx)
+			if err != nil {
+				return nil, err
+			}
+			uris := make([]string, len(roots))
+			for i, root := range roots {
+				uris[i] = root.URI
+			}
+			return mcp.NewToolResultText(strings.Join(uris, ",")), nil
+		},
+	)
+
+	testServer := server.NewTestServer(mcpServer)
+	defer testServer.Close()
+
+	client, err := NewSSEMCPClient(testServer.URL + "/sse")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Start(ctx); err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+
+	if err := client.SetRoots(ctx, []mcp.Root{{URI: "file:///one"}}); err != nil {
+		t.Fatalf("SetRoots failed: %v", err)
+	}
+
+	initRequest := mcp.InitializeRequest{}
+	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	if _, err := client.Initialize(ctx, initRequest); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	callRoots := func() string {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = "roots"
+		result, err := client.CallTool(ctx, request)
+		if err != nil {
+			t.Fatalf("CallTool failed: %v", err)
+		}
+		content, _ := result.Content[0].(map[string]interface{})
+		text, _ := content["text"].(string)
+		return text
+	}
+
+	if roots := callRoots(); roots != "file:///one" {
+		t.Errorf("Expected roots file:///one, got %q", roots)
+	}
+
+	if err := client.SetRoots(ctx, []mcp.Root{
+		{URI: "file:///two"},
+		{URI: "file:///three"},
+	}); err != nil {
+		t.Fatalf("SetRoots failed: %v", err)
+	}
+
+	// The server refreshes its cached roots after the change notification
+	deadline := time.Now().Add(time.Second)
+	for {
+		roots := callRoots()
+		if roots == "file:///two,file:///three" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected updated roots, got %q", roots)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
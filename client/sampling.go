@@ -0,0 +1,32 @@
+package client
+
+import (
+	"context"
+
+	"github.com/shaneholloman/mcp-server-go/mcp"
+)
+
+// SamplingHandler creates messages with an LLM on behalf of a server that
+// sent a sampling/createMessage request. Registering one makes the client
+// advertise the sampling capability when it initializes.
+type SamplingHandler interface {
+	CreateMessage(
+		ctx context.Context,
+		request mcp.CreateMessageRequest,
+	) (*mcp.CreateMessageResult, error)
+}
+
+// SamplingHandlerFunc is an adapter to use an ordinary function as a
+// SamplingHandler
+type SamplingHandlerFunc func(
+	ctx context.Context,
+	request mcp.CreateMessageRequest,
+) (*mcp.CreateMessageResult, error)
+
+// CreateMessage calls f(ctx, request)
+func (f SamplingHandlerFunc) CreateMessage(
+	ctx context.Context,
+	request mcp.CreateMessageRequest,
+) (*mcp.CreateMessageResult, error) {
+	return f(ctx, request)
+}
@@ -0,0 +1,219 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/shaneholloman/mcp-server-go/mcp"
+	"github.com/shaneholloman/mcp-server-go/server"
+)
+
+// newSamplingServer creates a server with a tool that asks the client to
+// sample a message and returns the sampled text
+func newSamplingServer() *server.MCPServer {
+	mcpServer := server.NewMCPServer("test-server", "1.0.0")
+	mcpServer.AddTool(
+		mcp.NewTool("sample"),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			var sampling mcp.CreateMessageRequest
+			sampling.Params.Messages = []mcp.SamplingMessage{
+				{Role: mcp.RoleUser, Content: mcp.NewTextContent("ping")},
+			}
+			sampling.Params.MaxTokens = 10
+
+			result, err := server.ServerFromContext(ctx).RequestSampling(ctx, sampling)
+			if err != nil {
+				return mcp.NewToolResultText(fmt.Sprintf("error: %v", err)), nil
+			}
+			content, _ := result.Content.(map[string]interface{})
+			return mcp.NewToolResultText(
+				fmt.Sprintf("%s: %v", result.Model, content["text"]),
+			), nil
+		},
+	)
+	return mcpServer
+}
+
+// echoSampler answers sampling requests by echoing the last message
+var echoSampler = SamplingHandlerFunc(func(
+	ctx context.Context,
+	request mcp.CreateMessageRequest,
+) (*mcp.CreateMessageResult, error) {
+	messages := request.Params.Messages
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("no messages")
+	}
+	content, _ := messages[len(messages)-1].Content.(map[string]interface{})
+	return &mcp.CreateMessageResult{
+		SamplingMessage: mcp.SamplingMessage{
+			Role:    mcp.RoleAssistant,
+			Content: mcp.NewTextContent(fmt.Sprintf("echo %v", content["text"])),
+		},
+		Model: "echo-model",
+	}, nil
+})
+
+// callSampleTool initializes the client and calls the sample tool, returning
+// its text output
+func callSampleTool(t *testing.T, client MCPClient) string {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	initRequest := mcp.InitializeRequest{}
+	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	initRequest.Params.ClientInfo = mcp.Implementation{
+		Name:    "test-client",
+		Version: "1.0.0",
+	}
+	if _, err := client.Initialize(ctx, initRequest); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "sample"
+	result, err := client.CallTool(ctx, request)
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if len(result.Content) != 1 {
+		t.Fatalf("Expected 1 content item, got %d", len(result.Content))
+	}
+	content, _ := result.Content[0].(map[string]interface{})
+	text, _ := content["text"].(string)
+	return text
+}
+
+func TestSSEMCPClient_Sampling(t *testing.T) {
+	testServer := server.NewTestServer(newSamplingServer())
+	defer testServer.Close()
+
+	tests := []struct {
+		name     string
+		handler  SamplingHandler
+		expected string
+	}{
+		{
+			name:     "With sampling handler",
+			handler:  echoSampler,
+			expected: "echo-model: echo ping",
+		},
+		{
+			name:     "Without sampling handler",
+			expected: "error: client does not support sampling",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewSSEMCPClient(testServer.URL + "/sse")
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+			defer client.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := client.Start(ctx); err != nil {
+				t.Fatalf("Failed to start client: %v", err)
+			}
+			if tt.handler != nil {
+				client.SetSamplingHandler(tt.handler)
+			}
+
+			if text := callSampleTool(t, client); text != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, text)
+			}
+		})
+	}
+}
+
+func TestStdioMCPClient_Sampling(t *testing.T) {
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stdioServer := server.NewStdioServer(newSamplingServer())
+	stdioServer.SetErrorLogger(log.New(io.Discard, "", 0))
+	go stdioServer.Listen(ctx, serverIn, serverOut)
+
+	// Wire the client to the in-process server instead of a subprocess
+	client := &StdioMCPClient{
+		stdin:     clientOut,
+		stdout:    bufio.NewReader(clientIn),
+		responses: make(map[int64]chan *response),
+		done:      make(chan struct{}),
+	}
+	go client.readResponses()
+	defer func() {
+		close(client.done)
+		clientOut.Close()
+		serverOut.Close()
+	}()
+
+	client.SetSamplingHandler(echoSampler)
+
+	expected := "echo-model: echo ping"
+	if text := callSampleTool(t, client); text != expected {
+		t.Errorf("Expected %q, got %q", expected, text)
+	}
+}
+
+func TestSSEMCPClient_SamplingCancelled(t *testing.T) {
+	mcpServer := server.NewMCPServer("test-server", "1.0.0")
+	mcpServer.AddTool(
+		mcp.NewTool("sample"),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			// Gives up on the client, which tells it the request is cancelled
+			ctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+			defer cancel()
+			var sampling mcp.CreateMessageRequest
+			sampling.Params.MaxTokens = 10
+			_, err := server.ServerFromContext(ctx).RequestSampling(ctx, sampling)
+			return mcp.NewToolResultText(fmt.Sprintf("error: %v", err)), nil
+		},
+	)
+	testServer := server.NewTestServer(mcpServer)
+	defer testServer.Close()
+
+	client, err := NewSSEMCPClient(testServer.URL + "/sse")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Start(ctx); err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+
+	cancelled := make(chan error, 1)
+	client.SetSamplingHandler(SamplingHandlerFunc(func(
+		ctx context.Context,
+		request mcp.CreateMessageRequest,
+	) (*mcp.CreateMessageResult, error) {
+		select {
+		case <-ctx.Done():
+			cancelled <- ctx.Err()
+		case <-time.After(5 * time.Second):
+			cancelled <- nil
+		}
+		return nil, fmt.Errorf("not sampled")
+	}))
+
+	expected := "error: context deadline exceeded"
+	if text := callSampleTool(t, client); text != expected {
+		t.Errorf("Expected %q, got %q", expected, text)
+	}
+	if err := <-cancelled; err != context.Canceled {
+		t.Errorf("Expected the sampling handler to be cancelled, got %v", err)
+	}
+}
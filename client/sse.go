@@ -22,18 +22,21 @@ import (
 // while sending requests over regular HTTP POST calls. The client handles
 // automatic reconnection and message routing between requests and responses.
 type SSEMCPClient struct {
-	baseURL       *url.URL
-	endpoint      *url.URL
-	httpClient    *http.Client
-	requestID     atomic.Int64
-	responses     map[int64]chan *json.RawMessage
-	mu            sync.RWMutex
-	done          chan struct{}
-	initialized   bool
-	notifications []func(mcp.JSONRPCNotification)
-	notifyMu      sync.RWMutex
-	endpointChan  chan struct{}
-	capabilities  mcp.ServerCapabilities
+	baseURL         *url.URL
+	endpoint        *url.URL
+	httpClient      *http.Client
+	requestID       atomic.Int64
+	responses       map[int64]chan *response
+	mu              sync.RWMutex
+	done            chan struct{}
+	initialized     bool
+	notifications   []func(mcp.JSONRPCNotification)
+	samplingHandler SamplingHandler
+	rootsProvider   RootsProvider
+	serverRequests  serverRequests
+	notifyMu        sync.RWMutex
+	endpointChan    chan struct{}
+	capabilities    mcp.ServerCapabilities
 }
 
 // NewSSEMCPClient creates a new SSE-based MCP client with the given base URL.
@@ -47,7 +50,7 @@ func NewSSEMCPClient(baseURL string) (*SSEMCPClient, error) {
 	return &SSEMCPClient{
 		baseURL:      parsedURL,
 		httpClient:   &http.Client{},
-		responses:    make(map[int64]chan *json.RawMessage),
+		responses:    make(map[int64]chan *response),
 		done:         make(chan struct{}),
 		endpointChan: make(chan struct{}),
 	}, nil
@@ -134,7 +137,8 @@ func (c *SSEMCPClient) readSSE(reader io.ReadCloser) {
 }
 
 // handleSSEEvent processes SSE events based on their type.
-// Handles 'endpoint' events for connection setup and 'message' events for JSON-RPC communication.
+// Handles 'endpoint' events for connection setup and 'message' events for JSON-RPC communication,
+// which carry responses, notifications and requests initiated by the server.
 func (c *SSEMCPClient) handleSSEEvent(event, data string) {
 	switch event {
 	case "endpoint":
@@ -153,13 +157,10 @@ func (c *SSEMCPClient) handleSSEEvent(event, data string) {
 	case "message":
 		var baseMessage struct {
 			JSONRPC string          `json:"jsonrpc"`
-			ID      *int64          `json:"id,omitempty"`
+			ID      json.RawMessage `json:"id,omitempty"`
 			Method  string          `json:"method,omitempty"`
 			Result  json.RawMessage `json:"result,omitempty"`
-			Error   *struct {
-				Code    int    `json:"code"`
-				Message string `json:"message"`
-			} `json:"error,omitempty"`
+			Error   *mcp.Error      `json:"error,omitempty"`
 		}
 
 		if err := json.Unmarshal([]byte(data), &baseMessage); err != nil {
@@ -173,6 +174,9 @@ func (c *SSEMCPClient) handleSSEEvent(event, data string) {
 			if err := json.Unmarshal([]byte(data), &notification); err != nil {
 				return
 			}
+			if notification.Method == "notifications/cancelled" {
+				c.serverRequests.cancel(notification)
+			}
 			c.notifyMu.RLock()
 			for _, handler := range c.notifications {
 				handler(notification)
@@ -181,23 +185,102 @@ func (c *SSEMCPClient) handleSSEEvent(event, data string) {
 			return
 		}
 
+		// Handle request from the server without blocking the stream
+		if baseMessage.Method != "" {
+			ctx, finish := c.serverRequests.start(baseMessage.ID)
+			go c.handleServerRequest(
+				ctx,
+				finish,
+				baseMessage.ID,
+				baseMessage.Method,
+				[]byte(data),
+			)
+			return
+		}
+
+		var id int64
+		if err := json.Unmarshal(baseMessage.ID, &id); err != nil {
+			return
+		}
+
 		c.mu.RLock()
-		ch, ok := c.responses[*baseMessage.ID]
+		ch, ok := c.responses[id]
 		c.mu.RUnlock()
 
 		if ok {
 			if baseMessage.Error != nil {
-				ch <- nil // Signal error condition
+				ch <- &response{err: baseMessage.Error}
 			} else {
-				ch <- &baseMessage.Result
+				ch <- &response{result: &baseMessage.Result}
 			}
 			c.mu.Lock()
-			delete(c.responses, *baseMessage.ID)
+			delete(c.responses, id)
 			c.mu.Unlock()
 		}
 	}
 }
 
+// handleServerRequest answers a request from the server and posts the
+// response back to the message endpoint. ctx is cancelled if the server
+// cancels the request, which then gets no response.
+func (c *SSEMCPClient) handleServerRequest(
+	ctx context.Context,
+	finish func() bool,
+	id json.RawMessage,
+	method string,
+	message []byte,
+) {
+	c.notifyMu.RLock()
+	handlers := serverRequestHandlers{
+		sampling: c.samplingHandler,
+		roots:    c.rootsProvider,
+	}
+	c.notifyMu.RUnlock()
+
+	response := handleServerRequest(
+		ctx,
+		handlers,
+		id,
+		method,
+		message,
+	)
+	// The server gave up on a cancelled request, so it gets no response
+	if finish() {
+		return
+	}
+	if err := c.postMessage(context.Background(), response); err != nil {
+		fmt.Printf("Error sending response: %v\n", err)
+	}
+}
+
+// postMessage sends a JSON-RPC message that expects no reply, such as a
+// notification or a response, to the message endpoint
+func (c *SSEMCPClient) postMessage(ctx context.Context, message interface{}) error {
+	messageBytes, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"POST",
+		c.endpoint.String(),
+		bytes.NewReader(messageBytes),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
 // OnNotification registers a handler function to be called when notifications are received.
 // Multiple handlers can be registered and will be called in the order they were added.
 func (c *SSEMCPClient) OnNotification(
@@ -208,6 +291,37 @@ func (c *SSEMCPClient) OnNotification(
 	c.notifications = append(c.notifications, handler)
 }
 
+// SetSamplingHandler registers the handler that answers sampling/createMessage
+// requests from the server. It must be called before Initialize for the
+// client to advertise the sampling capability.
+func (c *SSEMCPClient) SetSamplingHandler(handler SamplingHandler) {
+	c.notifyMu.Lock()
+	defer c.notifyMu.Unlock()
+	c.samplingHandler = handler
+}
+
+// SetRootsProvider registers the provider that answers roots/list requests
+// from the server. It must be called before Initialize for the client to
+// advertise the roots capability.
+func (c *SSEMCPClient) SetRootsProvider(provider RootsProvider) {
+	c.notifyMu.Lock()
+	defer c.notifyMu.Unlock()
+	c.rootsProvider = provider
+}
+
+// SetRoots replaces the roots exposed to the server with a fixed list. Once
+// the client is initialized, the server is notified that the roots changed.
+func (c *SSEMCPClient) SetRoots(ctx context.Context, roots []mcp.Root) error {
+	c.SetRootsProvider(staticRoots(append([]mcp.Root{}, roots...)))
+	if !c.initialized {
+		return nil
+	}
+	if err := c.postMessage(ctx, rootsListChangedNotification); err != nil {
+		return fmt.Errorf("failed to send roots list changed notification: %w", err)
+	}
+	return nil
+}
+
 // sendRequest sends a JSON-RPC request to the server and waits for a response.
 // Returns the raw JSON response message or an error if the request fails.
 func (c *SSEMCPClient) sendRequest(
@@ -225,22 +339,17 @@ func (c *SSEMCPClient) sendRequest(
 
 	id := c.requestID.Add(1)
 
-	request := mcp.JSONRPCRequest{
+	// Params are sent as given; mcp.Request only models their _meta field
+	request := struct {
+		JSONRPC string      `json:"jsonrpc"`
+		ID      int64       `json:"id"`
+		Method  string      `json:"method"`
+		Params  interface{} `json:"params,omitempty"`
+	}{
 		JSONRPC: mcp.JSONRPC_VERSION,
 		ID:      id,
-		Request: mcp.Request{
-			Method: method,
-		},
-	}
-
-	if params != nil {
-		paramsBytes, err := json.Marshal(params)
-		if err != nil {
-			return nil, fmt.Errorf("failed to marshal params: %w", err)
-		}
-		if err := json.Unmarshal(paramsBytes, &request.Params); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal params: %w", err)
-		}
+		Method:  method,
+		Params:  params,
 	}
 
 	requestBytes, err := json.Marshal(request)
@@ -248,7 +357,7 @@ func (c *SSEMCPClient) sendRequest(
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	responseChan := make(chan *json.RawMessage, 1)
+	responseChan := make(chan *response, 1)
 	c.mu.Lock()
 	c.responses[id] = responseChan
 	c.mu.Unlock()
@@ -273,7 +382,18 @@ func (c *SSEMCPClient) sendRequest(
 
 	if resp.StatusCode != http.StatusOK &&
 		resp.StatusCode != http.StatusAccepted {
+		c.mu.Lock()
+		delete(c.responses, id)
+		c.mu.Unlock()
+
 		body, _ := io.ReadAll(resp.Body)
+		// Rejections such as rate limiting carry a JSON-RPC error
+		var errorResponse struct {
+			Error *mcp.Error `json:"error"`
+		}
+		if json.Unmarshal(body, &errorResponse) == nil && errorResponse.Error != nil {
+			return nil, errorResponse.Error
+		}
 		return nil, fmt.Errorf(
 			"request failed with status %d: %s",
 			resp.StatusCode,
@@ -291,7 +411,7 @@ func (c *SSEMCPClient) sendRequest(
 		if response == nil {
 			return nil, fmt.Errorf("request failed")
 		}
-		return response, nil
+		return response.result, response.err
 	}
 }
 
@@ -310,6 +430,20 @@ func (c *SSEMCPClient) Initialize(
 		Capabilities:    request.Params.Capabilities, // Will be empty struct if not set
 	}
 
+	// Advertise the server requests the client can answer
+	c.notifyMu.RLock()
+	if c.samplingHandler != nil && params.Capabilities.Sampling == nil {
+		params.Capabilities.Sampling = &struct{}{}
+	}
+	if c.rootsProvider != nil && params.Capabilities.Roots == nil {
+		params.Capabilities.Roots = &struct {
+			ListChanged bool `json:"listChanged,omitempty"`
+		}{
+			ListChanged: true,
+		}
+	}
+	c.notifyMu.RUnlock()
+
 	response, err := c.sendRequest(ctx, "initialize", params)
 	if err != nil {
 		return nil, err
@@ -331,34 +465,12 @@ func (c *SSEMCPClient) Initialize(
 		},
 	}
 
-	notificationBytes, err := json.Marshal(notification)
-	if err != nil {
-		return nil, fmt.Errorf(
-			"failed to marshal initialized notification: %w",
-			err,
-		)
-	}
-
-	req, err := http.NewRequestWithContext(
-		ctx,
-		"POST",
-		c.endpoint.String(),
-		bytes.NewReader(notificationBytes),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create notification request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
+	if err := c.postMessage(ctx, notification); err != nil {
 		return nil, fmt.Errorf(
 			"failed to send initialized notification: %w",
 			err,
 		)
 	}
-	resp.Body.Close()
 
 	c.initialized = true
 	return &result, nil
@@ -549,13 +661,14 @@ func (c *SSEMCPClient) Close() error {
 	default:
 		close(c.done)
 	}
+	c.serverRequests.cancelAll()
 
 	// Clean up any pending responses
 	c.mu.Lock()
 	for _, ch := range c.responses {
 		close(ch)
 	}
-	c.responses = make(map[int64]chan *json.RawMessage)
+	c.responses = make(map[int64]chan *response)
 	c.mu.Unlock()
 
 	return nil
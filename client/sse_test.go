@@ -2,6 +2,7 @@ package client
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -30,6 +31,11 @@ func TestSSEMCPClient(t *testing.T) {
 		return &mcp.CallToolResult{}, nil
 	})
 
+	// Add a tool that fails with a structured error
+	mcpServer.AddTool(mcp.NewTool("failing-tool"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return nil, mcp.NewError(mcp.INVALID_PARAMS, "invalid city", map[string]interface{}{"field": "city"})
+	})
+
 	// Initialize
 	testServer := server.NewTestServer(mcpServer)
 	defer testServer.Close()
@@ -163,6 +169,50 @@ func TestSSEMCPClient(t *testing.T) {
 		}
 	})
 
+	t.Run("Decodes structured errors", func(t *testing.T) {
+		client, err := NewSSEMCPClient(testServer.URL + "/sse")
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		defer client.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := client.Start(ctx); err != nil {
+			t.Fatalf("Failed to start client: %v", err)
+		}
+
+		initRequest := mcp.InitializeRequest{}
+		initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+		initRequest.Params.ClientInfo = mcp.Implementation{
+			Name:    "test-client",
+			Version: "1.0.0",
+		}
+		if _, err := client.Initialize(ctx, initRequest); err != nil {
+			t.Fatalf("Failed to initialize: %v", err)
+		}
+
+		request := mcp.CallToolRequest{}
+		request.Params.Name = "failing-tool"
+		_, err = client.CallTool(ctx, request)
+
+		var rpcErr *mcp.Error
+		if !errors.As(err, &rpcErr) {
+			t.Fatalf("Expected *mcp.Error, got %v", err)
+		}
+		if rpcErr.Code != mcp.INVALID_PARAMS {
+			t.Errorf("Expected error code %d, got %d", mcp.INVALID_PARAMS, rpcErr.Code)
+		}
+		if rpcErr.Message != "invalid city" {
+			t.Errorf("Expected message 'invalid city', got '%s'", rpcErr.Message)
+		}
+		data, ok := rpcErr.Data.(map[string]interface{})
+		if !ok || data["field"] != "city" {
+			t.Errorf("Expected error data with field 'city', got %v", rpcErr.Data)
+		}
+	})
+
 	// t.Run("Handles context cancellation", func(t *testing.T) {
 	// 	client, err := NewSSEMCPClient(testServer.URL + "/sse")
 	// 	if err != nil {
@@ -184,3 +234,50 @@ func TestSSEMCPClient(t *testing.T) {
 	// 	}
 	// })
 }
+
+func TestSSEMCPClient_RateLimited(t *testing.T) {
+	mcpServer := server.NewMCPServer(
+		"test-server",
+		"1.0.0",
+		server.WithRateLimit(
+			server.NewTokenBucketLimiter(0.001, 1),
+			server.RateLimitBySession,
+		),
+	)
+	testServer := server.NewTestServer(mcpServer)
+	defer testServer.Close()
+
+	client, err := NewSSEMCPClient(testServer.URL + "/sse")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := client.Start(ctx); err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+
+	initRequest := mcp.InitializeRequest{}
+	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	initRequest.Params.ClientInfo = mcp.Implementation{
+		Name:    "test-client",
+		Version: "1.0.0",
+	}
+	if _, err := client.Initialize(ctx, initRequest); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+
+	// The initialize request used up the session's only token
+	err = client.Ping(ctx)
+
+	var rpcErr *mcp.Error
+	if !errors.As(err, &rpcErr) {
+		t.Fatalf("Expected *mcp.Error, got %v", err)
+	}
+	if rpcErr.Code != mcp.RATE_LIMITED {
+		t.Errorf("Expected error code %d, got %d", mcp.RATE_LIMITED, rpcErr.Code)
+	}
+}
@@ -19,17 +19,21 @@ import (
 // using JSON-RPC messages. The client handles message routing between requests and
 // responses, and supports asynchronous notifications.
 type StdioMCPClient struct {
-	cmd           *exec.Cmd
-	stdin         io.WriteCloser
-	stdout        *bufio.Reader
-	requestID     atomic.Int64
-	responses     map[int64]chan *json.RawMessage
-	mu            sync.RWMutex
-	done          chan struct{}
-	initialized   bool
-	notifications []func(mcp.JSONRPCNotification)
-	notifyMu      sync.RWMutex
-	capabilities  mcp.ServerCapabilities
+	cmd             *exec.Cmd
+	stdin           io.WriteCloser
+	stdinMu         sync.Mutex
+	stdout          *bufio.Reader
+	requestID       atomic.Int64
+	responses       map[int64]chan *response
+	mu              sync.RWMutex
+	done            chan struct{}
+	initialized     bool
+	notifications   []func(mcp.JSONRPCNotification)
+	samplingHandler SamplingHandler
+	rootsProvider   RootsProvider
+	serverRequests  serverRequests
+	notifyMu        sync.RWMutex
+	capabilities    mcp.ServerCapabilities
 }
 
 // NewStdioMCPClient creates a new stdio-based MCP client that communicates with a subprocess.
@@ -61,7 +65,7 @@ func NewStdioMCPClient(
 		cmd:       cmd,
 		stdin:     stdin,
 		stdout:    bufio.NewReader(stdout),
-		responses: make(map[int64]chan *json.RawMessage),
+		responses: make(map[int64]chan *response),
 		done:      make(chan struct{}),
 	}
 
@@ -84,6 +88,7 @@ func NewStdioMCPClient(
 // Returns an error if there are issues closing stdin or waiting for the subprocess to terminate.
 func (c *StdioMCPClient) Close() error {
 	close(c.done)
+	c.serverRequests.cancelAll()
 	if err := c.stdin.Close(); err != nil {
 		return fmt.Errorf("failed to close stdin: %w", err)
 	}
@@ -100,8 +105,40 @@ func (c *StdioMCPClient) OnNotification(
 	c.notifications = append(c.notifications, handler)
 }
 
+// SetSamplingHandler registers the handler that answers sampling/createMessage
+// requests from the server. It must be called before Initialize for the
+// client to advertise the sampling capability.
+func (c *StdioMCPClient) SetSamplingHandler(handler SamplingHandler) {
+	c.notifyMu.Lock()
+	defer c.notifyMu.Unlock()
+	c.samplingHandler = handler
+}
+
+// SetRootsProvider registers the provider that answers roots/list requests
+// from the server. It must be called before Initialize for the client to
+// advertise the roots capability.
+func (c *StdioMCPClient) SetRootsProvider(provider RootsProvider) {
+	c.notifyMu.Lock()
+	defer c.notifyMu.Unlock()
+	c.rootsProvider = provider
+}
+
+// SetRoots replaces the roots exposed to the server with a fixed list. Once
+// the client is initialized, the server is notified that the roots changed.
+func (c *StdioMCPClient) SetRoots(ctx context.Context, roots []mcp.Root) error {
+	c.SetRootsProvider(staticRoots(append([]mcp.Root{}, roots...)))
+	if !c.initialized {
+		return nil
+	}
+	if err := c.writeMessage(rootsListChangedNotification); err != nil {
+		return fmt.Errorf("failed to send roots list changed notification: %w", err)
+	}
+	return nil
+}
+
 // readResponses continuously reads and processes responses from the server's stdout.
-// It handles both responses to requests and notifications, routing them appropriately.
+// It handles responses to requests, notifications and requests initiated by the
+// server, routing them appropriately.
 // Runs until the done channel is closed or an error occurs reading from stdout.
 func (c *StdioMCPClient) readResponses() {
 	for {
@@ -119,13 +156,10 @@ func (c *StdioMCPClient) readResponses() {
 
 			var baseMessage struct {
 				JSONRPC string          `json:"jsonrpc"`
-				ID      *int64          `json:"id,omitempty"`
+				ID      json.RawMessage `json:"id,omitempty"`
 				Method  string          `json:"method,omitempty"`
 				Result  json.RawMessage `json:"result,omitempty"`
-				Error   *struct {
-					Code    int    `json:"code"`
-					Message string `json:"message"`
-				} `json:"error,omitempty"`
+				Error   *mcp.Error      `json:"error,omitempty"`
 			}
 
 			if err := json.Unmarshal([]byte(line), &baseMessage); err != nil {
@@ -138,6 +172,9 @@ func (c *StdioMCPClient) readResponses() {
 				if err := json.Unmarshal([]byte(line), &notification); err != nil {
 					continue
 				}
+				if notification.Method == "notifications/cancelled" {
+					c.serverRequests.cancel(notification)
+				}
 				c.notifyMu.RLock()
 				for _, handler := range c.notifications {
 					handler(notification)
@@ -146,24 +183,90 @@ func (c *StdioMCPClient) readResponses() {
 				continue
 			}
 
+			// Handle request from the server without blocking the read loop
+			if baseMessage.Method != "" {
+				ctx, finish := c.serverRequests.start(baseMessage.ID)
+				go c.handleServerRequest(
+					ctx,
+					finish,
+					baseMessage.ID,
+					baseMessage.Method,
+					[]byte(line),
+				)
+				continue
+			}
+
+			var id int64
+			if err := json.Unmarshal(baseMessage.ID, &id); err != nil {
+				continue
+			}
+
 			c.mu.RLock()
-			ch, ok := c.responses[*baseMessage.ID]
+			ch, ok := c.responses[id]
 			c.mu.RUnlock()
 
 			if ok {
 				if baseMessage.Error != nil {
-					ch <- nil // Signal error condition
+					ch <- &response{err: baseMessage.Error}
 				} else {
-					ch <- &baseMessage.Result
+					ch <- &response{result: &baseMessage.Result}
 				}
 				c.mu.Lock()
-				delete(c.responses, *baseMessage.ID)
+				delete(c.responses, id)
 				c.mu.Unlock()
 			}
 		}
 	}
 }
 
+// handleServerRequest answers a request from the server and writes the
+// response back over stdin. ctx is cancelled if the server cancels the
+// request, which then gets no response.
+func (c *StdioMCPClient) handleServerRequest(
+	ctx context.Context,
+	finish func() bool,
+	id json.RawMessage,
+	method string,
+	message []byte,
+) {
+	c.notifyMu.RLock()
+	handlers := serverRequestHandlers{
+		sampling: c.samplingHandler,
+		roots:    c.rootsProvider,
+	}
+	c.notifyMu.RUnlock()
+
+	response := handleServerRequest(
+		ctx,
+		handlers,
+		id,
+		method,
+		message,
+	)
+	// The server gave up on a cancelled request, so it gets no response
+	if finish() {
+		return
+	}
+	if err := c.writeMessage(response); err != nil {
+		fmt.Printf("Error writing response: %v\n", err)
+	}
+}
+
+// writeMessage writes a JSON-RPC message to the server's stdin, followed by
+// a newline. Concurrent writes are serialized.
+func (c *StdioMCPClient) writeMessage(message interface{}) error {
+	messageBytes, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	messageBytes = append(messageBytes, '\n')
+
+	c.stdinMu.Lock()
+	defer c.stdinMu.Unlock()
+	_, err = c.stdin.Write(messageBytes)
+	return err
+}
+
 // sendRequest sends a JSON-RPC request to the server and waits for a response.
 // It creates a unique request ID, sends the request over stdin, and waits for
 // the corresponding response or context cancellation.
@@ -192,18 +295,12 @@ func (c *StdioMCPClient) sendRequest(
 		Params:  params,
 	}
 
-	responseChan := make(chan *json.RawMessage, 1)
+	responseChan := make(chan *response, 1)
 	c.mu.Lock()
 	c.responses[id] = responseChan
 	c.mu.Unlock()
 
-	requestBytes, err := json.Marshal(request)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
-	}
-	requestBytes = append(requestBytes, '\n')
-
-	if _, err := c.stdin.Write(requestBytes); err != nil {
+	if err := c.writeMessage(request); err != nil {
 		return nil, fmt.Errorf("failed to write request: %w", err)
 	}
 
@@ -217,7 +314,7 @@ func (c *StdioMCPClient) sendRequest(
 		if response == nil {
 			return nil, fmt.Errorf("request failed")
 		}
-		return response, nil
+		return response.result, response.err
 	}
 }
 
@@ -241,6 +338,20 @@ func (c *StdioMCPClient) Initialize(
 		Capabilities:    request.Params.Capabilities, // Will be empty struct if not set
 	}
 
+	// Advertise the server requests the client can answer
+	c.notifyMu.RLock()
+	if c.samplingHandler != nil && params.Capabilities.Sampling == nil {
+		params.Capabilities.Sampling = &struct{}{}
+	}
+	if c.rootsProvider != nil && params.Capabilities.Roots == nil {
+		params.Capabilities.Roots = &struct {
+			ListChanged bool `json:"listChanged,omitempty"`
+		}{
+			ListChanged: true,
+		}
+	}
+	c.notifyMu.RUnlock()
+
 	response, err := c.sendRequest(ctx, "initialize", params)
 	if err != nil {
 		return nil, err
@@ -262,16 +373,7 @@ func (c *StdioMCPClient) Initialize(
 		},
 	}
 
-	notificationBytes, err := json.Marshal(notification)
-	if err != nil {
-		return nil, fmt.Errorf(
-			"failed to marshal initialized notification: %w",
-			err,
-		)
-	}
-	notificationBytes = append(notificationBytes, '\n')
-
-	if _, err := c.stdin.Write(notificationBytes); err != nil {
+	if err := c.writeMessage(notification); err != nil {
 		return nil, fmt.Errorf(
 			"failed to send initialized notification: %w",
 			err,
@@ -29,10 +29,9 @@ const (
 )
 
 type MCPServer struct {
-	server        *server.MCPServer
-	subscriptions map[string]bool
-	updateTicker  *time.Ticker
-	allResources  []mcp.Resource
+	server       *server.MCPServer
+	updateTicker *time.Ticker
+	allResources []mcp.Resource
 }
 
 func NewMCPServer() *MCPServer {
@@ -44,9 +43,8 @@ func NewMCPServer() *MCPServer {
 			server.WithPromptCapabilities(true),
 			server.WithLogging(),
 		),
-		subscriptions: make(map[string]bool),
-		updateTicker:  time.NewTicker(5 * time.Second),
-		allResources:  generateResources(),
+		updateTicker: time.NewTicker(5 * time.Second),
+		allResources: generateResources(),
 	}
 
 	s.server.AddResource(mcp.NewResource("test://static/resource",
@@ -113,24 +111,17 @@ func NewMCPServer() *MCPServer {
 		),
 	), s.handleLongRunningOperationTool)
 
-	// s.server.AddTool(mcp.Tool{
-	// 	Name:        string(SAMPLE_LLM),
-	// 	Description: "Samples from an LLM using MCP's sampling feature",
-	// 	InputSchema: mcp.ToolInputSchema{
-	// 		Type: "object",
-	// 		Properties: map[string]interface{}{
-	// 			"prompt": map[string]interface{}{
-	// 				"type":        "string",
-	// 				"description": "The prompt to send to the LLM",
-	// 			},
-	// 			"maxTokens": map[string]interface{}{
-	// 				"type":        "number",
-	// 				"description": "Maximum number of tokens to generate",
-	// 				"default":     100,
-	// 			},
-	// 		},
-	// 	},
-	// }, s.handleSampleLLMTool)
+	s.server.AddTool(mcp.NewTool(string(SAMPLE_LLM),
+		mcp.WithDescription("Samples from an LLM using MCP's sampling feature"),
+		mcp.WithString("prompt",
+			mcp.Description("The prompt to send to the LLM"),
+			mcp.Required(),
+		),
+		mcp.WithNumber("maxTokens",
+			mcp.Description("Maximum number of tokens to generate"),
+			mcp.DefaultNumber(100),
+		),
+	), s.handleSampleLLMTool)
 	s.server.AddTool(mcp.NewTool(string(GET_TINY_IMAGE),
 		mcp.WithDescription("Returns the MCP_TINY_IMAGE"),
 	), s.handleGetTinyImageTool)
@@ -164,24 +155,12 @@ func generateResources() []mcp.Resource {
 }
 
 func (s *MCPServer) runUpdateInterval() {
-	// for range s.updateTicker.C {
-	// 	for uri := range s.subscriptions {
-	// 		s.server.HandleMessage(
-	// 			context.Background(),
-	// 			mcp.JSONRPCNotification{
-	// 				JSONRPC: mcp.JSONRPC_VERSION,
-	// 				Notification: mcp.Notification{
-	// 					Method: "resources/updated",
-	// 					Params: struct {
-	// 						Meta map[string]interface{} `json:"_meta,omitempty"`
-	// 					}{
-	// 						Meta: map[string]interface{}{"uri": uri},
-	// 					},
-	// 				},
-	// 			},
-	// 		)
-	// 	}
-	// }
+	for range s.updateTicker.C {
+		// Only sessions subscribed to the resource are notified
+		if err := s.server.NotifyResourceUpdated("test://static/resource"); err != nil {
+			log.Printf("Failed to notify resource update: %v", err)
+		}
+	}
 }
 
 func (s *MCPServer) handleReadResource(
@@ -209,7 +188,7 @@ func (s *MCPServer) handleResourceTemplate(
 				URI:      request.Params.URI,
 				MIMEType: "text/plain",
 			},
-			Text: "This is a sample resource",
+			Text: fmt.Sprintf("This is sample resource %s", request.Params.Arguments["id"]),
 		},
 	}, nil
 }
@@ -318,6 +297,7 @@ func (s *MCPServer) handleSendNotification(
 	server := server.ServerFromContext(ctx)
 
 	err := server.SendNotificationToClient(
+		ctx,
 		"notifications/progress",
 		map[string]interface{}{
 			"progress":      10,
@@ -352,24 +332,18 @@ func (s *MCPServer) handleLongRunningOperationTool(
 	request mcp.CallToolRequest,
 ) (*mcp.CallToolResult, error) {
 	arguments := request.Params.Arguments
-	progressToken := request.Params.Meta.ProgressToken
 	duration, _ := arguments["duration"].(float64)
 	steps, _ := arguments["steps"].(float64)
 	stepDuration := duration / steps
-	server := server.ServerFromContext(ctx)
+	progress := server.ProgressReporterFromContext(ctx)
 
 	for i := 1; i < int(steps)+1; i++ {
 		time.Sleep(time.Duration(stepDuration * float64(time.Second)))
-		if progressToken != nil {
-			server.SendNotificationToClient(
-				"notifications/progress",
-				map[string]interface{}{
-					"progress":      i,
-					"total":         int(steps),
-					"progressToken": progressToken,
-				},
-			)
-		}
+		progress.Report(
+			float64(i),
+			steps,
+			fmt.Sprintf("Completed step %d of %d", i, int(steps)),
+		)
 	}
 
 	return &mcp.CallToolResult{
@@ -386,26 +360,44 @@ func (s *MCPServer) handleLongRunningOperationTool(
 	}, nil
 }
 
-// func (s *MCPServer) handleSampleLLMTool(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
-// 	prompt, _ := arguments["prompt"].(string)
-// 	maxTokens, _ := arguments["maxTokens"].(float64)
-
-// 	// This is a mock implementation. In a real scenario, you would use the server's RequestSampling method.
-// 	result := fmt.Sprintf(
-// 		"Sample LLM result for prompt: '%s' (max tokens: %d)",
-// 		prompt,
-// 		int(maxTokens),
-// 	)
-
-// 	return &mcp.CallToolResult{
-// 		Content: []interface{}{
-// 			mcp.TextContent{
-// 				Type: "text",
-// 				Text: fmt.Sprintf("LLM sampling result: %s", result),
-// 			},
-// 		},
-// 	}, nil
-// }
+func (s *MCPServer) handleSampleLLMTool(
+	ctx context.Context,
+	request mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	arguments := request.Params.Arguments
+	prompt, _ := arguments["prompt"].(string)
+	maxTokens, ok := arguments["maxTokens"].(float64)
+	if !ok {
+		maxTokens = 100
+	}
+
+	var sampling mcp.CreateMessageRequest
+	sampling.Params.Messages = []mcp.SamplingMessage{
+		{
+			Role: mcp.RoleUser,
+			Content: mcp.TextContent{
+				Type: "text",
+				Text: fmt.Sprintf("Resource sampleLLM context: %s", prompt),
+			},
+		},
+	}
+	sampling.Params.SystemPrompt = "You are a helpful assistant."
+	sampling.Params.MaxTokens = int(maxTokens)
+
+	result, err := server.ServerFromContext(ctx).RequestSampling(ctx, sampling)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sample: %w", err)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []interface{}{
+			mcp.TextContent{
+				Type: "text",
+				Text: fmt.Sprintf("LLM sampling result: %v", result.Content),
+			},
+		},
+	}, nil
+}
 
 func (s *MCPServer) handleGetTinyImageTool(
 	ctx context.Context,
@@ -160,6 +160,32 @@ const (
 	INTERNAL_ERROR   = -32603
 )
 
+// MCP specific error codes
+const (
+	RESOURCE_NOT_FOUND = -32002
+	// RATE_LIMITED rejects a request that exceeded a rate limit. Its data
+	// holds the number of seconds to wait before retrying as "retryAfter".
+	RATE_LIMITED = -32029
+)
+
+// Error is a JSON-RPC error with a code, message and optional data. Handlers
+// return it, possibly wrapped, to send the client a specific error instead
+// of INTERNAL_ERROR, and clients return it for error responses, so callers
+// can inspect it with errors.As.
+type Error struct {
+	// The error type that occurred.
+	Code int `json:"code"`
+	// A short description of the error.
+	Message string `json:"message"`
+	// Additional information about the error.
+	Data interface{} `json:"data,omitempty"`
+}
+
+// Error returns the error message
+func (e *Error) Error() string {
+	return e.Message
+}
+
 /* Empty result */
 
 // EmptyResult represents a response that indicates success but carries no data.
@@ -305,6 +331,8 @@ type ProgressNotification struct {
 		Progress float64 `json:"progress"`
 		// Total number of items to process (or total progress required), if known.
 		Total float64 `json:"total,omitempty"`
+		// An optional message describing the current progress.
+		Message string `json:"message,omitempty"`
 	} `json:"params"`
 }
 
@@ -691,6 +719,12 @@ type CompleteResult struct {
 	} `json:"completion"`
 }
 
+// Reference types used in the ref field of a CompleteRequest.
+const (
+	PromptReferenceType   = "ref/prompt"
+	ResourceReferenceType = "ref/resource"
+)
+
 // ResourceReference is a reference to a resource or resource template definition.
 type ResourceReference struct {
 	Type string `json:"type"`
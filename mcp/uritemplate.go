@@ -0,0 +1,607 @@
+package mcp
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// URITemplate is a parsed RFC 6570 URI template. It supports every operator
+// and modifier of level 4 and can both expand templates into URIs and match
+// URIs against them.
+type URITemplate struct {
+	raw         string
+	parts       []templatePart
+	expressions []*templateExpression
+	pattern     *regexp.Regexp
+	literals    int
+}
+
+// templatePart is either a literal or an expression of a URI template
+type templatePart struct {
+	literal    string
+	expression *templateExpression
+}
+
+// templateExpression is an expression such as {?query,page} of a URI
+// template
+type templateExpression struct {
+	operator templateOperator
+	varspecs []templateVarspec
+	// family holds the varspecs of every expression in the template whose
+	// values share a syntax, so the named values matched by one expression
+	// can be assigned to the variables of another, like {?q}{&page}
+	family []templateVarspec
+}
+
+// templateVarspec is a variable of an expression with its modifiers
+type templateVarspec struct {
+	name    string
+	prefix  int
+	explode bool
+}
+
+// templateOperator describes how an operator expands its variables
+type templateOperator struct {
+	char     byte
+	first    string
+	sep      string
+	named    bool
+	ifEmpty  string
+	reserved bool
+}
+
+var templateOperators = map[byte]templateOperator{
+	0:   {sep: ","},
+	'+': {char: '+', sep: ",", reserved: true},
+	'.': {char: '.', first: ".", sep: "."},
+	'/': {char: '/', first: "/", sep: "/"},
+	';': {char: ';', first: ";", sep: ";", named: true},
+	'?': {char: '?', first: "?", sep: "&", named: true, ifEmpty: "="},
+	'&': {char: '&', first: "&", sep: "&", named: true, ifEmpty: "="},
+	'#': {char: '#', first: "#", sep: ",", reserved: true},
+}
+
+var varnamePattern = regexp.MustCompile(
+	`^(?:[A-Za-z0-9_]|%[0-9A-Fa-f]{2})(?:\.?(?:[A-Za-z0-9_]|%[0-9A-Fa-f]{2}))*$`,
+)
+
+// ParseURITemplate parses an RFC 6570 URI template
+func ParseURITemplate(template string) (*URITemplate, error) {
+	t := &URITemplate{raw: template}
+	rest := template
+	for rest != "" {
+		start := strings.IndexAny(rest, "{}")
+		if start < 0 {
+			t.addLiteral(rest)
+			break
+		}
+		if rest[start] == '}' {
+			return nil, fmt.Errorf("unexpected '}' in URI template %q", template)
+		}
+		if start > 0 {
+			t.addLiteral(rest[:start])
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed expression in URI template %q", template)
+		}
+		expression, err := parseTemplateExpression(rest[start+1 : start+end])
+		if err != nil {
+			return nil, fmt.Errorf("invalid URI template %q: %w", template, err)
+		}
+		t.parts = append(t.parts, templatePart{expression: expression})
+		t.expressions = append(t.expressions, expression)
+		rest = rest[start+end+1:]
+	}
+
+	t.linkFamilies()
+	pattern, err := regexp.Compile(t.buildPattern())
+	if err != nil {
+		return nil, fmt.Errorf("invalid URI template %q: %w", template, err)
+	}
+	t.pattern = pattern
+	return t, nil
+}
+
+// MustParseURITemplate is like ParseURITemplate but panics if the template
+// is invalid
+func MustParseURITemplate(template string) *URITemplate {
+	t, err := ParseURITemplate(template)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func (t *URITemplate) addLiteral(literal string) {
+	t.parts = append(t.parts, templatePart{literal: literal})
+	t.literals += utf8.RuneCountInString(literal)
+}
+
+func parseTemplateExpression(body string) (*templateExpression, error) {
+	if body == "" {
+		return nil, fmt.Errorf("empty expression")
+	}
+	operator, ok := templateOperators[body[0]]
+	if ok {
+		body = body[1:]
+	} else if strings.IndexByte("=,!@|", body[0]) >= 0 {
+		return nil, fmt.Errorf("reserved operator %q", body[0])
+	} else {
+		operator = templateOperators[0]
+	}
+
+	expression := &templateExpression{operator: operator}
+	for _, spec := range strings.Split(body, ",") {
+		varspec := templateVarspec{name: spec}
+		if name, ok := strings.CutSuffix(spec, "*"); ok {
+			varspec.name = name
+			varspec.explode = true
+		} else if name, prefix, ok := strings.Cut(spec, ":"); ok {
+			length, err := strconv.Atoi(prefix)
+			if err != nil || length < 1 || length > 9999 || prefix[0] == '0' {
+				return nil, fmt.Errorf("invalid prefix length in %q", spec)
+			}
+			varspec.name = name
+			varspec.prefix = length
+		}
+		if !varnamePattern.MatchString(varspec.name) {
+			return nil, fmt.Errorf("invalid variable name %q", varspec.name)
+		}
+		expression.varspecs = append(expression.varspecs, varspec)
+	}
+	return expression, nil
+}
+
+// linkFamilies gives every named expression the varspecs of all the
+// expressions it shares a syntax with
+func (t *URITemplate) linkFamilies() {
+	families := map[byte][]templateVarspec{}
+	for _, expression := range t.expressions {
+		key := familyKey(expression.operator)
+		families[key] = append(families[key], expression.varspecs...)
+	}
+	for _, expression := range t.expressions {
+		expression.family = families[familyKey(expression.operator)]
+	}
+}
+
+func familyKey(operator templateOperator) byte {
+	if operator.char == '&' {
+		return '?'
+	}
+	return operator.char
+}
+
+// maxPrefixPattern is the longest prefix modifier that is enforced by the
+// pattern itself, as regular expressions limit repeat counts. Longer ones
+// are only checked once a URI has matched.
+const maxPrefixPattern = 100
+
+// pctEncodedChar matches a single UTF-8 encoded character that was
+// pct-encoded, as a prefix modifier counts characters rather than bytes
+const pctEncodedChar = `%[0-7][0-9A-Fa-f]` +
+	`|%[CDcd][0-9A-Fa-f]%[89ABab][0-9A-Fa-f]` +
+	`|%[Ee][0-9A-Fa-f](?:%[89ABab][0-9A-Fa-f]){2}` +
+	`|%[Ff][0-7](?:%[89ABab][0-9A-Fa-f]){3}`
+
+// prefixPattern returns a group lazily matching between one and prefix
+// characters, each of them either in chars or pct-encoded
+func prefixPattern(chars string, prefix int) string {
+	return fmt.Sprintf(`((?:[%s%%]|%s){1,%d}?)`, chars, pctEncodedChar, prefix)
+}
+
+// buildPattern returns a regular expression matching the URIs the template
+// expands to, with one group capturing the expansion of each expression
+func (t *URITemplate) buildPattern() string {
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	for _, part := range t.parts {
+		if part.expression == nil {
+			pattern.WriteString(regexp.QuoteMeta(part.literal))
+			continue
+		}
+		expression := part.expression
+		prefix := 0
+		if len(expression.varspecs) == 1 && expression.varspecs[0].prefix <= maxPrefixPattern {
+			prefix = expression.varspecs[0].prefix
+		}
+		// Simple and reserved expansions match lazily so that expressions
+		// following them, like the {.ext} of {name}{.ext}, get their share
+		switch expression.operator.char {
+		case 0:
+			if prefix > 0 {
+				pattern.WriteString(prefixPattern(`^/?#`, prefix))
+			} else {
+				pattern.WriteString(`([^/?#]+?)`)
+			}
+		case '+':
+			if prefix > 0 {
+				pattern.WriteString(prefixPattern(`^?#`, prefix))
+			} else {
+				pattern.WriteString(`([^?#]+?)`)
+			}
+		case '#':
+			pattern.WriteString(`(?:#(.*))?`)
+		case '/':
+			if len(expression.varspecs) == 1 && !expression.varspecs[0].explode {
+				pattern.WriteString(`(?:/([^/?#]*))?`)
+			} else {
+				pattern.WriteString(`(?:/([^?#]*))?`)
+			}
+		case '?', '&':
+			pattern.WriteString(`(?:` + regexp.QuoteMeta(expression.operator.first) + `([^#]*))?`)
+		default:
+			pattern.WriteString(`(?:` + regexp.QuoteMeta(expression.operator.first) + `([^/?#]*))?`)
+		}
+	}
+	pattern.WriteString("$")
+	return pattern.String()
+}
+
+// String returns the template as it was parsed
+func (t *URITemplate) String() string {
+	return t.raw
+}
+
+// Varnames returns the names of the template's variables in order
+func (t *URITemplate) Varnames() []string {
+	var names []string
+	for _, expression := range t.expressions {
+		for _, varspec := range expression.varspecs {
+			names = append(names, varspec.name)
+		}
+	}
+	return names
+}
+
+// MoreSpecific reports whether t should take precedence over other when both
+// match a URI. Templates with more literal characters win, then those with
+// fewer expressions, then those without reserved expansions, and remaining
+// ties are broken by comparing the templates as strings.
+func (t *URITemplate) MoreSpecific(other *URITemplate) bool {
+	if t.literals != other.literals {
+		return t.literals > other.literals
+	}
+	if len(t.expressions) != len(other.expressions) {
+		return len(t.expressions) < len(other.expressions)
+	}
+	if t.reservedExpressions() != other.reservedExpressions() {
+		return t.reservedExpressions() < other.reservedExpressions()
+	}
+	return t.raw < other.raw
+}
+
+func (t *URITemplate) reservedExpressions() int {
+	count := 0
+	for _, expression := range t.expressions {
+		if expression.operator.reserved {
+			count++
+		}
+	}
+	return count
+}
+
+// Match reports whether uri is an expansion of the template and returns the
+// values of the variables it defines. Values are strings, except exploded
+// variables, which are []string, or map[string]string for exploded named
+// variables holding keys not otherwise declared in the template. Named
+// values such as query parameters may appear in any order, and undeclared
+// ones are ignored.
+func (t *URITemplate) Match(uri string) (map[string]interface{}, bool) {
+	indexes := t.pattern.FindStringSubmatchIndex(uri)
+	if indexes == nil {
+		return nil, false
+	}
+	values := map[string]interface{}{}
+	for i, expression := range t.expressions {
+		start, end := indexes[2*i+2], indexes[2*i+3]
+		if start < 0 {
+			continue
+		}
+		var err error
+		if expression.operator.named {
+			err = expression.matchNamed(uri[start:end], values)
+		} else {
+			err = expression.matchUnnamed(uri[start:end], values)
+		}
+		if err != nil {
+			return nil, false
+		}
+	}
+	// The prefix modifier truncates values, so no expansion holds a longer one
+	for _, expression := range t.expressions {
+		for _, varspec := range expression.varspecs {
+			value, ok := values[varspec.name].(string)
+			if ok && varspec.prefix > 0 && utf8.RuneCountInString(value) > varspec.prefix {
+				return nil, false
+			}
+		}
+	}
+	return values, true
+}
+
+func (e *templateExpression) matchUnnamed(
+	expansion string,
+	values map[string]interface{},
+) error {
+	if len(e.varspecs) == 1 && !e.varspecs[0].explode {
+		value, err := url.PathUnescape(expansion)
+		if err != nil {
+			return err
+		}
+		values[e.varspecs[0].name] = value
+		return nil
+	}
+
+	pieces := strings.Split(expansion, e.operator.sep)
+	for i, varspec := range e.varspecs {
+		if i >= len(pieces) {
+			break
+		}
+		if varspec.explode && i == len(e.varspecs)-1 {
+			list, err := unescapeAll(pieces[i:])
+			if err != nil {
+				return err
+			}
+			values[varspec.name] = list
+			break
+		}
+		value, err := url.PathUnescape(pieces[i])
+		if err != nil {
+			return err
+		}
+		values[varspec.name] = value
+	}
+	return nil
+}
+
+func (e *templateExpression) matchNamed(
+	expansion string,
+	values map[string]interface{},
+) error {
+	if expansion == "" {
+		return nil
+	}
+	varspecs := map[string]templateVarspec{}
+	var exploded *templateVarspec
+	for i, varspec := range e.family {
+		varspecs[varspec.name] = varspec
+		if varspec.explode && exploded == nil {
+			exploded = &e.family[i]
+		}
+	}
+
+	for _, pair := range strings.Split(expansion, e.operator.sep) {
+		rawName, rawValue, _ := strings.Cut(pair, "=")
+		name, err := url.PathUnescape(rawName)
+		if err != nil {
+			return err
+		}
+		value, err := url.PathUnescape(rawValue)
+		if err != nil {
+			return err
+		}
+		if varspec, ok := varspecs[name]; ok {
+			if varspec.explode {
+				list, _ := values[name].([]string)
+				values[name] = append(list, value)
+			} else {
+				values[name] = value
+			}
+			continue
+		}
+		if exploded != nil {
+			fields, ok := values[exploded.name].(map[string]string)
+			if !ok {
+				fields = map[string]string{}
+				values[exploded.name] = fields
+			}
+			fields[name] = value
+		}
+	}
+	return nil
+}
+
+func unescapeAll(pieces []string) ([]string, error) {
+	values := make([]string, len(pieces))
+	for i, piece := range pieces {
+		value, err := url.PathUnescape(piece)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// Expand expands the template with values. Values may be strings or other
+// scalars, lists such as []string, or maps such as map[string]string, whose
+// keys are expanded in sorted order. Missing and nil values, along with
+// empty lists and maps, are undefined and left out of the expansion.
+func (t *URITemplate) Expand(values map[string]interface{}) string {
+	var uri strings.Builder
+	for _, part := range t.parts {
+		if part.expression == nil {
+			uri.WriteString(part.literal)
+			continue
+		}
+		part.expression.expand(&uri, values)
+	}
+	return uri.String()
+}
+
+func (e *templateExpression) expand(uri *strings.Builder, values map[string]interface{}) {
+	op := e.operator
+	first := true
+	for _, varspec := range e.varspecs {
+		value, ok := templateValue(values[varspec.name])
+		if !ok {
+			continue
+		}
+		if first {
+			uri.WriteString(op.first)
+			first = false
+		} else {
+			uri.WriteString(op.sep)
+		}
+
+		switch value := value.(type) {
+		case string:
+			if op.named {
+				uri.WriteString(varspec.name)
+				if value == "" {
+					uri.WriteString(op.ifEmpty)
+					continue
+				}
+				uri.WriteString("=")
+			}
+			if varspec.prefix > 0 && utf8.RuneCountInString(value) > varspec.prefix {
+				value = string([]rune(value)[:varspec.prefix])
+			}
+			uri.WriteString(op.encode(value))
+		case []string:
+			e.expandList(uri, varspec, value)
+		case [][2]string:
+			e.expandPairs(uri, varspec, value)
+		}
+	}
+}
+
+func (e *templateExpression) expandList(
+	uri *strings.Builder,
+	varspec templateVarspec,
+	list []string,
+) {
+	op := e.operator
+	if !varspec.explode {
+		if op.named {
+			uri.WriteString(varspec.name + "=")
+		}
+		for i, item := range list {
+			if i > 0 {
+				uri.WriteString(",")
+			}
+			uri.WriteString(op.encode(item))
+		}
+		return
+	}
+	for i, item := range list {
+		if i > 0 {
+			uri.WriteString(op.sep)
+		}
+		if op.named {
+			uri.WriteString(varspec.name)
+			if item == "" {
+				uri.WriteString(op.ifEmpty)
+				continue
+			}
+			uri.WriteString("=")
+		}
+		uri.WriteString(op.encode(item))
+	}
+}
+
+func (e *templateExpression) expandPairs(
+	uri *strings.Builder,
+	varspec templateVarspec,
+	pairs [][2]string,
+) {
+	op := e.operator
+	if !varspec.explode {
+		if op.named {
+			uri.WriteString(varspec.name + "=")
+		}
+		for i, pair := range pairs {
+			if i > 0 {
+				uri.WriteString(",")
+			}
+			uri.WriteString(op.encode(pair[0]) + "," + op.encode(pair[1]))
+		}
+		return
+	}
+	for i, pair := range pairs {
+		if i > 0 {
+			uri.WriteString(op.sep)
+		}
+		uri.WriteString(op.encode(pair[0]))
+		if op.named && pair[1] == "" {
+			uri.WriteString(op.ifEmpty)
+			continue
+		}
+		uri.WriteString("=" + op.encode(pair[1]))
+	}
+}
+
+// templateValue normalizes a variable value to a string, a []string or a
+// sorted [][2]string, reporting whether it is defined
+func templateValue(value interface{}) (interface{}, bool) {
+	switch value := value.(type) {
+	case nil:
+		return nil, false
+	case string:
+		return value, true
+	case []string:
+		return value, len(value) > 0
+	case []interface{}:
+		list := make([]string, len(value))
+		for i, item := range value {
+			list[i] = fmt.Sprint(item)
+		}
+		return list, len(list) > 0
+	case map[string]string:
+		pairs := make([][2]string, 0, len(value))
+		for key, item := range value {
+			pairs = append(pairs, [2]string{key, item})
+		}
+		return sortPairs(pairs), len(pairs) > 0
+	case map[string]interface{}:
+		pairs := make([][2]string, 0, len(value))
+		for key, item := range value {
+			pairs = append(pairs, [2]string{key, fmt.Sprint(item)})
+		}
+		return sortPairs(pairs), len(pairs) > 0
+	}
+	return fmt.Sprint(value), true
+}
+
+func sortPairs(pairs [][2]string) [][2]string {
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i][0] < pairs[j][0] })
+	return pairs
+}
+
+// encode percent-encodes the characters of value the operator does not
+// allow unencoded
+func (op templateOperator) encode(value string) string {
+	var encoded strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case isUnreserved(c):
+			encoded.WriteByte(c)
+		case op.reserved && strings.IndexByte(":/?#[]@!$&'()*+,;=", c) >= 0:
+			encoded.WriteByte(c)
+		case op.reserved && c == '%' && i+2 < len(value) &&
+			isHex(value[i+1]) && isHex(value[i+2]):
+			encoded.WriteString(value[i : i+3])
+			i += 2
+		default:
+			fmt.Fprintf(&encoded, "%%%02X", c)
+		}
+	}
+	return encoded.String()
+}
+
+func isUnreserved(c byte) bool {
+	return 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
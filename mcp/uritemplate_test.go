@@ -0,0 +1,297 @@
+package mcp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestURITemplate_Expand(t *testing.T) {
+	// Variables and expected expansions from RFC 6570 section 3.2
+	values := map[string]interface{}{
+		"count": []string{"one", "two", "three"},
+		"dom":   []string{"example", "com"},
+		"dub":   "me/too",
+		"hello": "Hello World!",
+		"half":  "50%",
+		"var":   "value",
+		"who":   "fred",
+		"base":  "http://example.com/home/",
+		"path":  "/foo/bar",
+		"list":  []string{"red", "green", "blue"},
+		"keys":  map[string]string{"semi": ";", "dot": ".", "comma": ","},
+		"v":     "6",
+		"x":     "1024",
+		"y":     "768",
+		"empty": "",
+		"undef": nil,
+	}
+
+	tests := []struct {
+		template string
+		expected string
+	}{
+		{"{var}", "value"},
+		{"{hello}", "Hello%20World%21"},
+		{"{half}", "50%25"},
+		{"O{empty}X", "OX"},
+		{"O{undef}X", "OX"},
+		{"{x,y}", "1024,768"},
+		{"{x,hello,y}", "1024,Hello%20World%21,768"},
+		{"?{x,empty}", "?1024,"},
+		{"?{x,undef}", "?1024"},
+		{"{var:3}", "val"},
+		{"{list}", "red,green,blue"},
+		{"{list*}", "red,green,blue"},
+		{"{keys}", "comma,%2C,dot,.,semi,%3B"},
+		{"{keys*}", "comma=%2C,dot=.,semi=%3B"},
+		{"{+var}", "value"},
+		{"{+hello}", "Hello%20World!"},
+		{"{+half}", "50%25"},
+		{"{base}index", "http%3A%2F%2Fexample.com%2Fhome%2Findex"},
+		{"{+base}index", "http://example.com/home/index"},
+		{"{+path}/here", "/foo/bar/here"},
+		{"{+path:6}/here", "/foo/b/here"},
+		{"{+list*}", "red,green,blue"},
+		{"{#var}", "#value"},
+		{"{#hello}", "#Hello%20World!"},
+		{"{#path:6}/here", "#/foo/b/here"},
+		{"{#keys*}", "#comma=,,dot=.,semi=;"},
+		{"X{.var}", "X.value"},
+		{"X{.x,y}", "X.1024.768"},
+		{"X{.list*}", "X.red.green.blue"},
+		{"www{.dom*}", "www.example.com"},
+		{"X{.empty_keys}", "X"},
+		{"{/var}", "/value"},
+		{"{/var,x}/here", "/value/1024/here"},
+		{"{/list*,path:4}", "/red/green/blue/%2Ffoo"},
+		{"{/keys*}", "/comma=%2C/dot=./semi=%3B"},
+		{"{;x,y}", ";x=1024;y=768"},
+		{"{;x,y,empty}", ";x=1024;y=768;empty"},
+		{"{;list*}", ";list=red;list=green;list=blue"},
+		{"{;keys*}", ";comma=%2C;dot=.;semi=%3B"},
+		{"{?x,y}", "?x=1024&y=768"},
+		{"{?x,y,empty}", "?x=1024&y=768&empty="},
+		{"{?var:3}", "?var=val"},
+		{"{?list}", "?list=red,green,blue"},
+		{"{?list*}", "?list=red&list=green&list=blue"},
+		{"{?keys*}", "?comma=%2C&dot=.&semi=%3B"},
+		{"?fixed=yes{&x}", "?fixed=yes&x=1024"},
+		{"{&x,y,empty}", "&x=1024&y=768&empty="},
+		{"{&list*}", "&list=red&list=green&list=blue"},
+		{"{count}", "one,two,three"},
+		{"{dub}", "me%2Ftoo"},
+		{"{+dub}", "me/too"},
+		{"{who}{/v}", "fred/6"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			template, err := ParseURITemplate(tt.template)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, template.Expand(values))
+		})
+	}
+}
+
+func TestURITemplate_Match(t *testing.T) {
+	tests := []struct {
+		template string
+		uri      string
+		expected map[string]interface{}
+	}{
+		{
+			template: "test://items/{id}",
+			uri:      "test://items/42",
+			expected: map[string]interface{}{"id": "42"},
+		},
+		{
+			template: "test://items/{id}",
+			uri:      "test://items/a%20b",
+			expected: map[string]interface{}{"id": "a b"},
+		},
+		{
+			template: "test://items/{id}",
+			uri:      "test://items/42/extra",
+		},
+		{
+			template: "test://items/{id}",
+			uri:      "test://items/",
+		},
+		{
+			template: "test://repos/{owner}/{repo}",
+			uri:      "test://repos/golang/go",
+			expected: map[string]interface{}{"owner": "golang", "repo": "go"},
+		},
+		{
+			template: "file:///{+path}",
+			uri:      "file:///home/user/notes.txt",
+			expected: map[string]interface{}{"path": "home/user/notes.txt"},
+		},
+		{
+			template: "test://tree{/segments*}",
+			uri:      "test://tree/a/b/c",
+			expected: map[string]interface{}{"segments": []string{"a", "b", "c"}},
+		},
+		{
+			template: "test://tree{/segments*}",
+			uri:      "test://tree",
+			expected: map[string]interface{}{},
+		},
+		{
+			template: "test://search{?query,page}",
+			uri:      "test://search?page=2&query=go%20templates",
+			expected: map[string]interface{}{"query": "go templates", "page": "2"},
+		},
+		{
+			template: "test://search{?query,page}",
+			uri:      "test://search?query=go&unknown=1",
+			expected: map[string]interface{}{"query": "go"},
+		},
+		{
+			template: "test://search{?query}{&page}",
+			uri:      "test://search?query=go&page=3",
+			expected: map[string]interface{}{"query": "go", "page": "3"},
+		},
+		{
+			template: "test://search{?tags*}",
+			uri:      "test://search?tags=a&tags=b",
+			expected: map[string]interface{}{"tags": []string{"a", "b"}},
+		},
+		{
+			template: "test://search{?query,filters*}",
+			uri:      "test://search?query=go&lang=en&sort=new",
+			expected: map[string]interface{}{
+				"query":   "go",
+				"filters": map[string]string{"lang": "en", "sort": "new"},
+			},
+		},
+		{
+			template: "test://files/{name}{.ext}",
+			uri:      "test://files/report.pdf",
+			expected: map[string]interface{}{"name": "report", "ext": "pdf"},
+		},
+		{
+			template: "test://docs/{id}{#section}",
+			uri:      "test://docs/7#intro",
+			expected: map[string]interface{}{"id": "7", "section": "intro"},
+		},
+		{
+			template: "test://items/{id:3}",
+			uri:      "test://items/abc",
+			expected: map[string]interface{}{"id": "abc"},
+		},
+		{
+			template: "test://items/{id:3}",
+			uri:      "test://items/abcdef",
+		},
+		{
+			template: "test://items/{id:3}",
+			uri:      "test://items/%C3%A9t%C3%A9",
+			expected: map[string]interface{}{"id": "été"},
+		},
+		{
+			template: "test://items/{id:2}{.ext}",
+			uri:      "test://items/ab.txt",
+			expected: map[string]interface{}{"id": "ab", "ext": "txt"},
+		},
+		{
+			template: "test://items/{id:2}{.ext}",
+			uri:      "test://items/abc.txt",
+		},
+		{
+			template: "file:///{+path:5}",
+			uri:      "file:///a/b/cdef",
+		},
+		{
+			template: "test://search{?query:3}",
+			uri:      "test://search?query=golang",
+		},
+		{
+			template: "test://items/{id:200}",
+			uri:      "test://items/" + strings.Repeat("a", 201),
+		},
+		{
+			template: "test://matrix{;x,y}",
+			uri:      "test://matrix;y=768;x=1024",
+			expected: map[string]interface{}{"x": "1024", "y": "768"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template+" "+tt.uri, func(t *testing.T) {
+			template, err := ParseURITemplate(tt.template)
+			require.NoError(t, err)
+			values, ok := template.Match(tt.uri)
+			if tt.expected == nil {
+				assert.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			assert.Equal(t, tt.expected, values)
+		})
+	}
+}
+
+func TestURITemplate_MatchExpansion(t *testing.T) {
+	template := MustParseURITemplate("test://users/{user}/files{/path*}{?version,format}")
+	values := map[string]interface{}{
+		"user":    "ada lovelace",
+		"path":    []string{"notes", "2024"},
+		"version": "3",
+		"format":  "md",
+	}
+
+	uri := template.Expand(values)
+	assert.Equal(t, "test://users/ada%20lovelace/files/notes/2024?version=3&format=md", uri)
+
+	matched, ok := template.Match(uri)
+	require.True(t, ok)
+	assert.Equal(t, map[string]interface{}{
+		"user":    "ada lovelace",
+		"path":    []string{"notes", "2024"},
+		"version": "3",
+		"format":  "md",
+	}, matched)
+}
+
+func TestURITemplate_MoreSpecific(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected bool
+	}{
+		{"test://items/{id}", "test://{+path}", true},
+		{"test://items/{id}/raw", "test://items/{id}/{format}", true},
+		{"test://items/{id}", "test://items/{+id}", true},
+		{"test://a/{x}", "test://b/{x}", true},
+		{"test://b/{x}", "test://a/{x}", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			a := MustParseURITemplate(tt.a)
+			b := MustParseURITemplate(tt.b)
+			assert.Equal(t, tt.expected, a.MoreSpecific(b))
+			assert.Equal(t, !tt.expected, b.MoreSpecific(a))
+		})
+	}
+}
+
+func TestParseURITemplate_Errors(t *testing.T) {
+	for _, template := range []string{
+		"test://items/{id",
+		"test://items/id}",
+		"test://items/{}",
+		"test://items/{=id}",
+		"test://items/{id:0}",
+		"test://items/{id:abc}",
+		"test://items/{bad name}",
+	} {
+		t.Run(template, func(t *testing.T) {
+			_, err := ParseURITemplate(template)
+			assert.Error(t, err)
+		})
+	}
+}
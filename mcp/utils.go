@@ -1,6 +1,9 @@
 package mcp
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
 
 // ClientRequest types
 var _ ClientRequest = &PingRequest{}
@@ -132,6 +135,27 @@ func NewJSONRPCError(
 	}
 }
 
+// NewError creates a JSON-RPC error with the given code, message and
+// optional data
+func NewError(code int, message string, data interface{}) *Error {
+	return &Error{
+		Code:    code,
+		Message: message,
+		Data:    data,
+	}
+}
+
+// NewJSONRPCErrorFromError creates the error response to the request with
+// the given id for an error returned by a handler. An *Error anywhere in the
+// chain of err is sent as is; any other error becomes an INTERNAL_ERROR.
+func NewJSONRPCErrorFromError(id RequestId, err error) JSONRPCError {
+	var rpcErr *Error
+	if !errors.As(err, &rpcErr) {
+		return NewJSONRPCError(id, INTERNAL_ERROR, err.Error(), nil)
+	}
+	return NewJSONRPCError(id, rpcErr.Code, rpcErr.Message, rpcErr.Data)
+}
+
 // Helper function for creating a progress notification
 func NewProgressNotification(
 	token ProgressToken,
@@ -146,6 +170,7 @@ func NewProgressNotification(
 			ProgressToken ProgressToken `json:"progressToken"`
 			Progress      float64       `json:"progress"`
 			Total         float64       `json:"total,omitempty"`
+			Message       string        `json:"message,omitempty"`
 		}{
 			ProgressToken: token,
 			Progress:      progress,
@@ -360,6 +385,15 @@ func NewInitializeResult(
 	}
 }
 
+// NewCompleteResult creates a new CompleteResult
+func NewCompleteResult(values []string, total int, hasMore bool) *CompleteResult {
+	result := &CompleteResult{}
+	result.Completion.Values = values
+	result.Completion.Total = total
+	result.Completion.HasMore = hasMore
+	return result
+}
+
 // Helper for formatting numbers in tool results
 func FormatNumberResult(value float64) *CallToolResult {
 	return NewToolResultText(fmt.Sprintf("%.2f", value))
@@ -0,0 +1,50 @@
+package mcp
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewJSONRPCErrorFromError(t *testing.T) {
+	tests := []struct {
+		name            string
+		err             error
+		expectedCode    int
+		expectedMessage string
+		expectedData    interface{}
+	}{
+		{
+			name:            "Plain error",
+			err:             errors.New("backend unavailable"),
+			expectedCode:    INTERNAL_ERROR,
+			expectedMessage: "backend unavailable",
+		},
+		{
+			name:            "Structured error",
+			err:             NewError(RESOURCE_NOT_FOUND, "Resource not found", map[string]interface{}{"uri": "test://a"}),
+			expectedCode:    RESOURCE_NOT_FOUND,
+			expectedMessage: "Resource not found",
+			expectedData:    map[string]interface{}{"uri": "test://a"},
+		},
+		{
+			name:            "Wrapped structured error",
+			err:             fmt.Errorf("reading: %w", NewError(INVALID_PARAMS, "Bad URI", nil)),
+			expectedCode:    INVALID_PARAMS,
+			expectedMessage: "Bad URI",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := NewJSONRPCErrorFromError(1, tt.err)
+			assert.Equal(t, JSONRPC_VERSION, response.JSONRPC)
+			assert.Equal(t, 1, response.ID)
+			assert.Equal(t, tt.expectedCode, response.Error.Code)
+			assert.Equal(t, tt.expectedMessage, response.Error.Message)
+			assert.Equal(t, tt.expectedData, response.Error.Data)
+		})
+	}
+}
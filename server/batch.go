@@ -0,0 +1,88 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/shaneholloman/mcp-server-go/mcp"
+)
+
+// WithBatchConcurrency sets how many messages of a JSON-RPC batch are
+// handled at once. By default they are handled one after another, in order.
+// Responses are always returned in the order of the batch.
+func WithBatchConcurrency(limit int) ServerOption {
+	return func(s *MCPServer) {
+		if limit < 1 {
+			limit = 1
+		}
+		s.batchConcurrency = limit
+	}
+}
+
+// isBatch reports whether message is a JSON array
+func isBatch(message json.RawMessage) bool {
+	trimmed := bytes.TrimLeft(message, " \t\r\n")
+	return len(trimmed) > 0 && trimmed[0] == '['
+}
+
+// handleBatch handles each message of a JSON-RPC batch and returns the
+// responses to its requests
+func (s *MCPServer) handleBatch(
+	ctx context.Context,
+	message json.RawMessage,
+) mcp.JSONRPCMessage {
+	var batch []json.RawMessage
+	if err := json.Unmarshal(message, &batch); err != nil {
+		return createErrorResponse(nil, mcp.PARSE_ERROR, "Failed to parse batch")
+	}
+	if len(batch) == 0 {
+		return createErrorResponse(nil, mcp.INVALID_REQUEST, "Empty batch")
+	}
+
+	responses := make([]mcp.JSONRPCMessage, len(batch))
+	handle := func(i int) {
+		// Each message of a batch must itself be a request, notification
+		// or response object
+		trimmed := bytes.TrimLeft(batch[i], " \t\r\n")
+		if len(trimmed) == 0 || trimmed[0] != '{' {
+			responses[i] = createErrorResponse(nil, mcp.INVALID_REQUEST, "Invalid batch message")
+			return
+		}
+		responses[i] = s.handleMessage(ctx, batch[i])
+	}
+
+	if s.batchConcurrency == 1 {
+		for i := range batch {
+			handle(i)
+		}
+	} else {
+		var wg sync.WaitGroup
+		slots := make(chan struct{}, s.batchConcurrency)
+		for i := range batch {
+			slots <- struct{}{}
+			wg.Add(1)
+			go func(i int) {
+				defer func() {
+					<-slots
+					wg.Done()
+				}()
+				handle(i)
+			}(i)
+		}
+		wg.Wait()
+	}
+
+	// Notifications and responses get no response of their own
+	var results []mcp.JSONRPCMessage
+	for _, response := range responses {
+		if response != nil {
+			results = append(results, response)
+		}
+	}
+	if len(results) == 0 {
+		return nil
+	}
+	return results
+}
@@ -0,0 +1,183 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/shaneholloman/mcp-server-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createBatchTestServer(opts ...ServerOption) *MCPServer {
+	server := NewMCPServer("test-server", "1.0.0",
+		append([]ServerOption{
+			WithResourceCapabilities(false, false),
+			WithPromptCapabilities(false),
+		}, opts...)...,
+	)
+	server.AddTool(mcp.NewTool("echo"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("echo"), nil
+	})
+	return server
+}
+
+func TestMCPServer_HandleBatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		message  string
+		validate func(t *testing.T, response mcp.JSONRPCMessage)
+	}{
+		{
+			name: "Requests are answered in order",
+			message: `[
+                {"jsonrpc": "2.0", "id": 1, "method": "tools/list"},
+                {"jsonrpc": "2.0", "id": 2, "method": "prompts/list"},
+                {"jsonrpc": "2.0", "id": 3, "method": "resources/list"}
+            ]`,
+			validate: func(t *testing.T, response mcp.JSONRPCMessage) {
+				responses, ok := response.([]mcp.JSONRPCMessage)
+				require.True(t, ok)
+				require.Len(t, responses, 3)
+				for i, expected := range []interface{}{
+					mcp.ListToolsResult{},
+					mcp.ListPromptsResult{},
+					mcp.ListResourcesResult{},
+				} {
+					resp, ok := responses[i].(mcp.JSONRPCResponse)
+					require.True(t, ok)
+					assert.EqualValues(t, i+1, resp.ID)
+					assert.IsType(t, expected, resp.Result)
+				}
+			},
+		},
+		{
+			name: "Notifications are not answered",
+			message: `[
+                {"jsonrpc": "2.0", "method": "notifications/initialized"},
+                {"jsonrpc": "2.0", "id": 1, "method": "ping"},
+                {"jsonrpc": "2.0", "id": 2, "method": "unknown"}
+            ]`,
+			validate: func(t *testing.T, response mcp.JSONRPCMessage) {
+				responses, ok := response.([]mcp.JSONRPCMessage)
+				require.True(t, ok)
+				require.Len(t, responses, 2)
+				assert.IsType(t, mcp.JSONRPCResponse{}, responses[0])
+				errorResponse, ok := responses[1].(mcp.JSONRPCError)
+				require.True(t, ok)
+				assert.Equal(t, mcp.METHOD_NOT_FOUND, errorResponse.Error.Code)
+			},
+		},
+		{
+			name: "Only notifications",
+			message: `[
+                {"jsonrpc": "2.0", "method": "notifications/initialized"},
+                {"jsonrpc": "2.0", "method": "notifications/roots/list_changed"}
+            ]`,
+			validate: func(t *testing.T, response mcp.JSONRPCMessage) {
+				assert.Nil(t, response)
+			},
+		},
+		{
+			name:    "Empty batch",
+			message: `[]`,
+			validate: func(t *testing.T, response mcp.JSONRPCMessage) {
+				errorResponse, ok := response.(mcp.JSONRPCError)
+				require.True(t, ok)
+				assert.Equal(t, mcp.INVALID_REQUEST, errorResponse.Error.Code)
+				assert.Nil(t, errorResponse.ID)
+			},
+		},
+		{
+			name:    "Invalid messages",
+			message: `[1, {"jsonrpc": "2.0", "id": 1, "method": "ping"}, "ping"]`,
+			validate: func(t *testing.T, response mcp.JSONRPCMessage) {
+				responses, ok := response.([]mcp.JSONRPCMessage)
+				require.True(t, ok)
+				require.Len(t, responses, 3)
+				for _, i := range []int{0, 2} {
+					errorResponse, ok := responses[i].(mcp.JSONRPCError)
+					require.True(t, ok)
+					assert.Equal(t, mcp.INVALID_REQUEST, errorResponse.Error.Code)
+				}
+				assert.IsType(t, mcp.JSONRPCResponse{}, responses[1])
+			},
+		},
+		{
+			name:    "Malformed batch",
+			message: `[{"jsonrpc": "2.0", "id": 1, "method": "ping"}`,
+			validate: func(t *testing.T, response mcp.JSONRPCMessage) {
+				errorResponse, ok := response.(mcp.JSONRPCError)
+				require.True(t, ok)
+				assert.Equal(t, mcp.PARSE_ERROR, errorResponse.Error.Code)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := createBatchTestServer()
+			response := server.HandleMessage(context.Background(), []byte(tt.message))
+			tt.validate(t, response)
+		})
+	}
+}
+
+func TestMCPServer_HandleBatchConcurrently(t *testing.T) {
+	const size = 3
+	server := createBatchTestServer(WithBatchConcurrency(size))
+
+	// Every call waits for the others to start, so the batch only completes
+	// if its messages are handled at the same time
+	var started sync.WaitGroup
+	started.Add(size)
+	server.AddTool(mcp.NewTool("wait"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		started.Done()
+		started.Wait()
+		return mcp.NewToolResultText("done"), nil
+	})
+
+	batch := make([]json.RawMessage, size)
+	for i := range batch {
+		batch[i] = callToolMessage(i, "wait")
+	}
+	message, err := json.Marshal(batch)
+	require.NoError(t, err)
+
+	response := server.HandleMessage(context.Background(), message)
+	responses, ok := response.([]mcp.JSONRPCMessage)
+	require.True(t, ok)
+	require.Len(t, responses, size)
+	for i, response := range responses {
+		resp, ok := response.(mcp.JSONRPCResponse)
+		require.True(t, ok)
+		assert.EqualValues(t, i, resp.ID)
+	}
+}
+
+func TestSSEServer_Batch(t *testing.T) {
+	// Registered as a cleanup so that it runs after the stream is closed
+	testServer := NewTestServer(createBatchTestServer())
+	t.Cleanup(testServer.Close)
+
+	client := connectTestSSE(t, testServer.URL)
+	client.initialize(t)
+
+	client.post(t, []byte(`[
+        {"jsonrpc": "2.0", "id": 1, "method": "tools/list"},
+        {"jsonrpc": "2.0", "method": "notifications/roots/list_changed"},
+        {"jsonrpc": "2.0", "id": 2, "method": "ping"}
+    ]`))
+
+	var responses []struct {
+		ID     int             `json:"id"`
+		Result json.RawMessage `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(client.next(t)), &responses))
+	require.Len(t, responses, 2)
+	assert.Equal(t, 1, responses[0].ID)
+	assert.Contains(t, string(responses[0].Result), `"echo"`)
+	assert.Equal(t, 2, responses[1].ID)
+}
@@ -0,0 +1,239 @@
+package server
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shaneholloman/mcp-server-go/mcp"
+)
+
+// cacheOptions holds the TTL and size limit of a registration's cache
+type cacheOptions struct {
+	ttl        time.Duration
+	maxEntries int
+}
+
+// CacheToolResults caches the results of a single tool, keyed by its
+// arguments, for ttl. Once the cache holds maxEntries results the least
+// recently used one is evicted. A ttl of zero keeps results until they are
+// evicted or invalidated with InvalidateTool, and a maxEntries of zero puts
+// no limit on the size of the cache.
+//
+// Every call gets its own copy of a cached result, so middleware may change
+// the result it returns without affecting other calls. Only successful
+// results are cached; errors and results with IsError set are
+// not. Middleware and limits still run for every call, but a cached result
+// is returned without calling the handler. Use it only for tools whose
+// results depend on nothing but their arguments, as the cache is shared by
+// all sessions.
+func CacheToolResults(ttl time.Duration, maxEntries int) ToolRegistrationOption {
+	return func(r *toolRegistration) {
+		r.cache = &cacheOptions{ttl: ttl, maxEntries: maxEntries}
+	}
+}
+
+// CacheResource caches the contents of a single resource, or of the
+// resources read through a resource template, keyed by URI, for ttl. The
+// TTL and size limit work as with CacheToolResults, and cached contents are
+// dropped by InvalidateResource and InvalidateResourceTemplate.
+func CacheResource(ttl time.Duration, maxEntries int) ResourceRegistrationOption {
+	return func(r *resourceRegistration) {
+		r.cache = &cacheOptions{ttl: ttl, maxEntries: maxEntries}
+	}
+}
+
+// InvalidateTool drops every cached result of the named tool
+func (s *MCPServer) InvalidateTool(name string) {
+	s.mu.RLock()
+	entry, ok := s.tools[name]
+	s.mu.RUnlock()
+	if ok && entry.cache != nil {
+		entry.cache.clear()
+	}
+}
+
+// InvalidateResource drops the cached contents of uri, whether it was read
+// as a resource or through a resource template, and sends a
+// notifications/resources/updated notification to the sessions subscribed
+// to it.
+func (s *MCPServer) InvalidateResource(uri string) error {
+	s.mu.RLock()
+	if entry, ok := s.resources[uri]; ok && entry.cache != nil {
+		entry.cache.remove(uri)
+	}
+	for _, entry := range s.resourceTemplates {
+		if entry.cache != nil {
+			entry.cache.remove(uri)
+		}
+	}
+	s.mu.RUnlock()
+	return s.NotifyResourceUpdated(uri)
+}
+
+// InvalidateResourceTemplate drops the cached contents of every resource
+// read through the template registered as uriTemplate, and notifies the
+// sessions subscribed to any of them
+func (s *MCPServer) InvalidateResourceTemplate(uriTemplate string) error {
+	s.mu.RLock()
+	entry, ok := s.resourceTemplates[uriTemplate]
+	s.mu.RUnlock()
+	if !ok || entry.cache == nil {
+		return nil
+	}
+
+	var errs []error
+	for _, uri := range entry.cache.clear() {
+		if err := s.NotifyResourceUpdated(uri); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// cacheToolHandler returns a handler that serves the results of next from
+// cache, keyed by the canonical JSON encoding of the call's arguments
+func cacheToolHandler(cache *resultCache, next ToolHandlerFunc) ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Maps are encoded with sorted keys, so equal arguments share a key
+		arguments, err := json.Marshal(request.Params.Arguments)
+		if err != nil {
+			return next(ctx, request)
+		}
+		key := string(arguments)
+		if value, ok := cache.get(key); ok {
+			return copyToolResult(value.(*mcp.CallToolResult)), nil
+		}
+
+		result, err := next(ctx, request)
+		if err == nil && result != nil && !result.IsError {
+			cache.add(key, copyToolResult(result))
+		}
+		return result, err
+	}
+}
+
+// copyToolResult returns a copy of result that can be changed without
+// changing result. Content items are values, so copying the slice is enough.
+func copyToolResult(result *mcp.CallToolResult) *mcp.CallToolResult {
+	c := *result
+	c.Content = append([]interface{}(nil), result.Content...)
+	if result.Meta != nil {
+		c.Meta = make(map[string]interface{}, len(result.Meta))
+		for key, value := range result.Meta {
+			c.Meta[key] = value
+		}
+	}
+	return &c
+}
+
+// cacheResourceHandler returns a handler that serves the contents read by
+// next from cache, keyed by URI
+func cacheResourceHandler(cache *resultCache, next ResourceHandlerFunc) ResourceHandlerFunc {
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]interface{}, error) {
+		if value, ok := cache.get(request.Params.URI); ok {
+			return append([]interface{}(nil), value.([]interface{})...), nil
+		}
+
+		contents, err := next(ctx, request)
+		if err == nil {
+			cache.add(request.Params.URI, append([]interface{}(nil), contents...))
+		}
+		return contents, err
+	}
+}
+
+// resultCache is a cache with a TTL whose least recently used entries are
+// evicted when it is full
+type resultCache struct {
+	options cacheOptions
+	now     func() time.Time
+
+	mu      sync.Mutex
+	order   *list.List // of *cacheEntry, most recently used first
+	entries map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key     string
+	value   interface{}
+	expires time.Time
+}
+
+func newResultCache(options cacheOptions) *resultCache {
+	return &resultCache{
+		options: options,
+		now:     time.Now,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// get returns the value cached under key, unless it has expired
+func (c *resultCache) get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*cacheEntry)
+	if !entry.expires.IsZero() && !c.now().Before(entry.expires) {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return entry.value, true
+}
+
+// add caches value under key, evicting the least recently used entry if the
+// cache is full
+func (c *resultCache) add(key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := &cacheEntry{key: key, value: value}
+	if c.options.ttl > 0 {
+		entry.expires = c.now().Add(c.options.ttl)
+	}
+
+	if element, ok := c.entries[key]; ok {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	if c.options.maxEntries > 0 && c.order.Len() > c.options.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// remove drops the entry cached under key
+func (c *resultCache) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		c.order.Remove(element)
+		delete(c.entries, key)
+	}
+}
+
+// clear drops every entry and returns their keys in order
+func (c *resultCache) clear() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	keys := make([]string, 0, len(c.entries))
+	for key := range c.entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	c.order.Init()
+	c.entries = make(map[string]*list.Element)
+	return keys
+}
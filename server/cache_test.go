@@ -0,0 +1,257 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shaneholloman/mcp-server-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResultCache(t *testing.T) {
+	now := time.Unix(0, 0)
+	cache := newResultCache(cacheOptions{ttl: time.Minute, maxEntries: 2})
+	cache.now = func() time.Time { return now }
+
+	cache.add("a", 1)
+	cache.add("b", 2)
+	value, ok := cache.get("a")
+	require.True(t, ok)
+	assert.Equal(t, 1, value)
+
+	// b is the least recently used entry, so it is evicted first
+	cache.add("c", 3)
+	_, ok = cache.get("b")
+	assert.False(t, ok)
+	_, ok = cache.get("a")
+	assert.True(t, ok)
+
+	// Entries expire after the TTL
+	now = now.Add(30 * time.Second)
+	cache.add("d", 4)
+	now = now.Add(30 * time.Second)
+	_, ok = cache.get("a")
+	assert.False(t, ok)
+	value, ok = cache.get("d")
+	require.True(t, ok)
+	assert.Equal(t, 4, value)
+
+	cache.remove("d")
+	_, ok = cache.get("d")
+	assert.False(t, ok)
+
+	cache.add("e", 5)
+	cache.add("f", 6)
+	assert.Equal(t, []string{"e", "f"}, cache.clear())
+	_, ok = cache.get("e")
+	assert.False(t, ok)
+}
+
+func TestMCPServer_CacheToolResults(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0")
+	var calls atomic.Int64
+	server.AddTool(
+		mcp.NewTool("forecast"),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			n := calls.Add(1)
+			if request.Params.Arguments["city"] == "Atlantis" {
+				return mcp.NewToolResultError("unknown city"), nil
+			}
+			return mcp.NewToolResultText(fmt.Sprintf("forecast %d", n)), nil
+		},
+		CacheToolResults(time.Hour, 10),
+	)
+
+	call := func(arguments string) string {
+		t.Helper()
+		response := server.HandleMessage(context.Background(), []byte(fmt.Sprintf(
+			`{"jsonrpc": "2.0", "id": 1, "method": "tools/call", "params": {"name": "forecast", "arguments": %s}}`,
+			arguments,
+		)))
+		resp, ok := response.(mcp.JSONRPCResponse)
+		require.True(t, ok, "unexpected response %v", response)
+		return resultText(t, resp.Result.(*mcp.CallToolResult))
+	}
+
+	assert.Equal(t, "forecast 1", call(`{"city": "Oslo", "days": 3}`))
+	// The order of the arguments does not matter
+	assert.Equal(t, "forecast 1", call(`{"days": 3, "city": "Oslo"}`))
+	assert.Equal(t, "forecast 2", call(`{"city": "Oslo", "days": 5}`))
+
+	// Failed calls are not cached
+	assert.Equal(t, "unknown city", call(`{"city": "Atlantis"}`))
+	assert.Equal(t, "unknown city", call(`{"city": "Atlantis"}`))
+	assert.Equal(t, int64(4), calls.Load())
+
+	server.InvalidateTool("forecast")
+	assert.Equal(t, "forecast 5", call(`{"city": "Oslo", "days": 3}`))
+
+	// Cached calls still count in the tool's statistics
+	assert.Equal(t, int64(6), server.ToolStats()["forecast"].Calls)
+}
+
+func TestMCPServer_CacheResource(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0",
+		WithResourceCapabilities(true, true),
+	)
+	var reads atomic.Int64
+	handler := func(ctx context.Context, request mcp.ReadResourceRequest) ([]interface{}, error) {
+		n := reads.Add(1)
+		return []interface{}{mcp.TextResourceContents{
+			ResourceContents: mcp.ResourceContents{URI: request.Params.URI},
+			Text:             fmt.Sprintf("read %d", n),
+		}}, nil
+	}
+	server.AddResource(
+		mcp.NewResource("test://static", "Static"),
+		handler,
+		CacheResource(time.Hour, 10),
+	)
+	server.AddResourceTemplate(
+		mcp.NewResourceTemplate("test://items/{id}", "Item"),
+		handler,
+		CacheResource(time.Hour, 10),
+	)
+
+	read := func(uri string) string {
+		t.Helper()
+		response := server.HandleMessage(
+			context.Background(),
+			subscribeMessage("resources/read", uri),
+		)
+		resp, ok := response.(mcp.JSONRPCResponse)
+		require.True(t, ok, "unexpected response %v", response)
+		contents := resp.Result.(mcp.ReadResourceResult).Contents
+		require.Len(t, contents, 1)
+		return contents[0].(mcp.TextResourceContents).Text
+	}
+
+	assert.Equal(t, "read 1", read("test://static"))
+	assert.Equal(t, "read 1", read("test://static"))
+	assert.Equal(t, "read 2", read("test://items/1"))
+	assert.Equal(t, "read 3", read("test://items/2"))
+	assert.Equal(t, "read 2", read("test://items/1"))
+
+	ctx, session := newReadySession(t, server, "session-1")
+	_, ok := server.HandleMessage(
+		ctx,
+		subscribeMessage("resources/subscribe", "test://items/{id}"),
+	).(mcp.JSONRPCResponse)
+	require.True(t, ok)
+
+	notified := func() []string {
+		var uris []string
+		for _, notification := range drainNotifications(session) {
+			assert.Equal(t, "notifications/resources/updated", notification.Method)
+			uris = append(uris, notification.Params.AdditionalFields["uri"].(string))
+		}
+		return uris
+	}
+
+	// Invalidating a URI drops it and notifies its subscribers
+	require.NoError(t, server.InvalidateResource("test://items/1"))
+	assert.Equal(t, []string{"test://items/1"}, notified())
+	assert.Equal(t, "read 4", read("test://items/1"))
+	assert.Equal(t, "read 3", read("test://items/2"))
+
+	require.NoError(t, server.InvalidateResource("test://static"))
+	assert.Empty(t, notified())
+	assert.Equal(t, "read 5", read("test://static"))
+
+	// Invalidating a template drops every URI read through it
+	require.NoError(t, server.InvalidateResourceTemplate("test://items/{id}"))
+	assert.Equal(t, []string{"test://items/1", "test://items/2"}, notified())
+	assert.Equal(t, "read 6", read("test://items/2"))
+	assert.Equal(t, "read 5", read("test://static"))
+}
+
+func TestMCPServer_CacheIsolatedFromMiddleware(t *testing.T) {
+	var redacting atomic.Bool
+	redact := func(next ToolHandlerFunc) ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			result, err := next(ctx, request)
+			if err == nil && redacting.Load() {
+				result.Content[0] = mcp.NewTextContent("redacted")
+				result.Content = append(result.Content, mcp.NewTextContent("note"))
+				result.Meta = map[string]interface{}{"redacted": true}
+			}
+			return result, err
+		}
+	}
+	annotate := func(next ResourceHandlerFunc) ResourceHandlerFunc {
+		return func(ctx context.Context, request mcp.ReadResourceRequest) ([]interface{}, error) {
+			contents, err := next(ctx, request)
+			if err == nil {
+				contents[0] = mcp.TextResourceContents{Text: "annotated"}
+			}
+			return contents, err
+		}
+	}
+	server := NewMCPServer("test-server", "1.0.0",
+		WithResourceCapabilities(true, true),
+		WithResourceMiddleware(annotate),
+	)
+	var calls atomic.Int64
+	server.AddTool(
+		mcp.NewTool("secret"),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			calls.Add(1)
+			return mcp.NewToolResultText("secret"), nil
+		},
+		CacheToolResults(time.Hour, 10),
+		UseToolMiddleware(redact),
+	)
+	var reads atomic.Int64
+	server.AddResource(
+		mcp.NewResource("test://static", "Static"),
+		func(ctx context.Context, request mcp.ReadResourceRequest) ([]interface{}, error) {
+			reads.Add(1)
+			return []interface{}{mcp.TextResourceContents{Text: "original"}}, nil
+		},
+		CacheResource(time.Hour, 10),
+	)
+
+	call := func() *mcp.CallToolResult {
+		t.Helper()
+		response := server.HandleMessage(context.Background(), callToolMessage(1, "secret"))
+		resp, ok := response.(mcp.JSONRPCResponse)
+		require.True(t, ok, "unexpected response %v", response)
+		return resp.Result.(*mcp.CallToolResult)
+	}
+
+	// The first call caches the result before middleware changes it, and
+	// later calls do not see the changes
+	redacting.Store(true)
+	for i := 0; i < 2; i++ {
+		redacted := call()
+		require.Len(t, redacted.Content, 2)
+		assert.Equal(t, "redacted", redacted.Content[0].(mcp.TextContent).Text)
+	}
+	redacting.Store(false)
+	plain := call()
+	assert.Equal(t, "secret", resultText(t, plain))
+	assert.Nil(t, plain.Meta)
+	assert.Equal(t, int64(1), calls.Load())
+
+	for i := 0; i < 2; i++ {
+		response := server.HandleMessage(
+			context.Background(),
+			subscribeMessage("resources/read", "test://static"),
+		)
+		resp, ok := response.(mcp.JSONRPCResponse)
+		require.True(t, ok, "unexpected response %v", response)
+		contents := resp.Result.(mcp.ReadResourceResult).Contents
+		assert.Equal(t, "annotated", contents[0].(mcp.TextResourceContents).Text)
+	}
+	assert.Equal(t, int64(1), reads.Load())
+
+	// The cached contents are still the handler's
+	entry := server.resources["test://static"]
+	value, ok := entry.cache.get("test://static")
+	require.True(t, ok)
+	assert.Equal(t, "original", value.([]interface{})[0].(mcp.TextResourceContents).Text)
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/shaneholloman/mcp-server-go/mcp"
+)
+
+// inFlightRequest is a client request whose handler is still running
+type inFlightRequest struct {
+	cancel    context.CancelFunc
+	cancelled bool
+}
+
+// trackRequest registers a request from the client as in flight and returns
+// a context that is cancelled if the client cancels the request. The returned
+// function must be called once the request has been handled; it reports
+// whether the request was cancelled, in which case no response may be sent.
+func (c *ClientSession) trackRequest(
+	ctx context.Context,
+	id interface{},
+) (context.Context, func() bool) {
+	key, err := json.Marshal(id)
+	if err != nil {
+		return ctx, func() bool { return false }
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	request := &inFlightRequest{cancel: cancel}
+
+	c.inFlightMu.Lock()
+	c.inFlight[string(key)] = request
+	c.inFlightMu.Unlock()
+
+	return ctx, func() bool {
+		c.inFlightMu.Lock()
+		defer c.inFlightMu.Unlock()
+		if c.inFlight[string(key)] == request {
+			delete(c.inFlight, string(key))
+		}
+		cancel()
+		return request.cancelled
+	}
+}
+
+// cancelRequest cancels the in-flight request with the given ID. Unknown or
+// already completed requests are ignored.
+func (c *ClientSession) cancelRequest(id interface{}) {
+	key, err := json.Marshal(id)
+	if err != nil {
+		return
+	}
+
+	c.inFlightMu.Lock()
+	defer c.inFlightMu.Unlock()
+	if request, ok := c.inFlight[string(key)]; ok {
+		request.cancelled = true
+		request.cancel()
+	}
+}
+
+// cancelAllRequests cancels every in-flight request, e.g. when the
+// session is closed
+func (c *ClientSession) cancelAllRequests() {
+	c.inFlightMu.Lock()
+	defer c.inFlightMu.Unlock()
+	for _, request := range c.inFlight {
+		request.cancelled = true
+		request.cancel()
+	}
+}
+
+// handleCancelled cancels the request named by a notifications/cancelled
+// notification from the client
+func (s *MCPServer) handleCancelled(
+	ctx context.Context,
+	notification mcp.JSONRPCNotification,
+) {
+	requestID, ok := notification.Params.AdditionalFields["requestId"]
+	if !ok || requestID == nil {
+		return
+	}
+	if session := ClientSessionFromContext(ctx); session != nil {
+		session.cancelRequest(requestID)
+	}
+}
+
+// requestStartedKey is the context key of the function called once a request
+// has been registered as in flight
+type requestStartedKey struct{}
+
+// withRequestStarted returns a context whose requests call started once they
+// can be cancelled, which lets a transport that handles requests
+// concurrently wait for that before it handles the messages that follow
+func withRequestStarted(ctx context.Context, started func()) context.Context {
+	return context.WithValue(ctx, requestStartedKey{}, started)
+}
+
+// requestStarted calls the function set by withRequestStarted, if any
+func requestStarted(ctx context.Context) {
+	if started, ok := ctx.Value(requestStartedKey{}).(func()); ok {
+		started()
+	}
+}
@@ -0,0 +1,143 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/shaneholloman/mcp-server-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createBlockingToolServer creates a server with a tool that blocks until its
+// context is done, reporting when it starts and why it stopped
+func createBlockingToolServer() (*MCPServer, chan struct{}, chan error) {
+	started := make(chan struct{}, 1)
+	stopped := make(chan error, 1)
+
+	server := NewMCPServer("test-server", "1.0.0")
+	server.AddTool(
+		mcp.NewTool("block"),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			started <- struct{}{}
+			select {
+			case <-ctx.Done():
+				stopped <- ctx.Err()
+				return nil, ctx.Err()
+			case <-time.After(5 * time.Second):
+				stopped <- nil
+				return mcp.NewToolResultText("done"), nil
+			}
+		},
+	)
+	return server, started, stopped
+}
+
+func cancelledMessage(requestID interface{}) []byte {
+	return []byte(fmt.Sprintf(`{
+        "jsonrpc": "2.0",
+        "method": "notifications/cancelled",
+        "params": {"requestId": %v, "reason": "user stopped"}
+    }`, requestID))
+}
+
+func TestMCPServer_CancelRequest(t *testing.T) {
+	tests := []struct {
+		name      string
+		requestID interface{}
+		cancelID  interface{}
+		cancelled bool
+	}{
+		{
+			name:      "Matching numeric ID",
+			requestID: 1,
+			cancelID:  1,
+			cancelled: true,
+		},
+		{
+			name:      "Matching string ID",
+			requestID: `"abc"`,
+			cancelID:  `"abc"`,
+			cancelled: true,
+		},
+		{
+			name:      "String ID does not match numeric ID",
+			requestID: 1,
+			cancelID:  `"1"`,
+		},
+		{
+			name:      "Unknown ID",
+			requestID: 1,
+			cancelID:  2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, started, stopped := createBlockingToolServer()
+			ctx, _ := newReadySession(t, server, "session-1")
+
+			responses := make(chan mcp.JSONRPCMessage, 1)
+			go func() {
+				responses <- server.HandleMessage(ctx, []byte(fmt.Sprintf(`{
+                    "jsonrpc": "2.0",
+                    "id": %v,
+                    "method": "tools/call",
+                    "params": {"name": "block"}
+                }`, tt.requestID)))
+			}()
+			<-started
+
+			response := server.HandleMessage(ctx, cancelledMessage(tt.cancelID))
+			assert.Nil(t, response)
+
+			if !tt.cancelled {
+				select {
+				case err := <-stopped:
+					t.Fatalf("handler stopped unexpectedly: %v", err)
+				case <-time.After(50 * time.Millisecond):
+				}
+				// Let the handler finish by closing the session
+				server.UnregisterSession("session-1")
+			}
+
+			select {
+			case err := <-stopped:
+				assert.ErrorIs(t, err, context.Canceled)
+			case <-time.After(time.Second):
+				t.Fatal("handler was not cancelled")
+			}
+
+			// The response to a cancelled request is suppressed
+			select {
+			case response := <-responses:
+				assert.Nil(t, response)
+			case <-time.After(time.Second):
+				t.Fatal("HandleMessage did not return")
+			}
+		})
+	}
+}
+
+func TestMCPServer_CancelCompletedRequest(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0")
+	server.AddTool(
+		mcp.NewTool("quick"),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return mcp.NewToolResultText("done"), nil
+		},
+	)
+	ctx, session := newReadySession(t, server, "session-1")
+
+	response := server.HandleMessage(ctx, callToolMessage(1, "quick"))
+	require.IsType(t, mcp.JSONRPCResponse{}, response)
+
+	// Cancelling a request that already completed has no effect
+	assert.Nil(t, server.HandleMessage(ctx, cancelledMessage(1)))
+	assert.Empty(t, session.inFlight)
+
+	response = server.HandleMessage(ctx, callToolMessage(1, "quick"))
+	assert.IsType(t, mcp.JSONRPCResponse{}, response)
+}
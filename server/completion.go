@@ -0,0 +1,127 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/shaneholloman/mcp-server-go/mcp"
+)
+
+// maxCompletionValues is the maximum number of values a completion/complete
+// response may carry, as mandated by the MCP specification.
+const maxCompletionValues = 100
+
+// CompletionHandlerFunc returns the completion candidates for the argument in
+// the request. It may return any number of values; the server truncates the
+// response to the protocol limit and reports the total count to the client.
+type CompletionHandlerFunc func(ctx context.Context, request mcp.CompleteRequest) ([]string, error)
+
+// completionKey identifies a completion provider by reference and argument
+type completionKey struct {
+	refType  string
+	ref      string
+	argument string
+}
+
+// AddPromptCompletion registers a completion provider for an argument of the
+// prompt with the given name
+func (s *MCPServer) AddPromptCompletion(
+	promptName, argument string,
+	handler CompletionHandlerFunc,
+) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.completions[completionKey{
+		refType:  mcp.PromptReferenceType,
+		ref:      promptName,
+		argument: argument,
+	}] = handler
+}
+
+// AddResourceCompletion registers a completion provider for a variable of the
+// resource template with the given URI template
+func (s *MCPServer) AddResourceCompletion(
+	uriTemplate, variable string,
+	handler CompletionHandlerFunc,
+) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.completions[completionKey{
+		refType:  mcp.ResourceReferenceType,
+		ref:      uriTemplate,
+		argument: variable,
+	}] = handler
+}
+
+// parseCompletionRef decodes the untyped ref of a completion request into a
+// completionKey for the requested argument
+func parseCompletionRef(request mcp.CompleteRequest) (completionKey, error) {
+	refBytes, err := json.Marshal(request.Params.Ref)
+	if err != nil {
+		return completionKey{}, err
+	}
+
+	var ref struct {
+		Type string `json:"type"`
+		Name string `json:"name"`
+		URI  string `json:"uri"`
+	}
+	if err := json.Unmarshal(refBytes, &ref); err != nil {
+		return completionKey{}, fmt.Errorf("invalid completion reference")
+	}
+
+	key := completionKey{
+		refType:  ref.Type,
+		argument: request.Params.Argument.Name,
+	}
+	switch ref.Type {
+	case mcp.PromptReferenceType:
+		key.ref = ref.Name
+	case mcp.ResourceReferenceType:
+		key.ref = ref.URI
+	default:
+		return completionKey{}, fmt.Errorf(
+			"unsupported completion reference type: %q",
+			ref.Type,
+		)
+	}
+	return key, nil
+}
+
+func (s *MCPServer) handleComplete(
+	ctx context.Context,
+	id interface{},
+	request mcp.CompleteRequest,
+) mcp.JSONRPCMessage {
+	key, err := parseCompletionRef(request)
+	if err != nil {
+		return createErrorResponse(id, mcp.INVALID_PARAMS, err.Error())
+	}
+
+	s.mu.RLock()
+	handler, ok := s.completions[key]
+	s.mu.RUnlock()
+	if !ok {
+		// No provider means no suggestions rather than an error, so hosts
+		// can request completions for any argument unconditionally.
+		return createResponse(id, *mcp.NewCompleteResult([]string{}, 0, false))
+	}
+
+	values, err := handler(ctx, request)
+	if err != nil {
+		return mcp.NewJSONRPCErrorFromError(id, err)
+	}
+	if values == nil {
+		values = []string{}
+	}
+
+	total := len(values)
+	hasMore := false
+	if total > maxCompletionValues {
+		values = values[:maxCompletionValues]
+		hasMore = true
+	}
+
+	return createResponse(id, *mcp.NewCompleteResult(values, total, hasMore))
+}
@@ -0,0 +1,165 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/shaneholloman/mcp-server-go/mcp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMCPServer_Completion(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0")
+
+	branches := []string{"main", "master", "feature/login", "fix/typo"}
+	server.AddPromptCompletion(
+		"code_review",
+		"branch",
+		func(ctx context.Context, request mcp.CompleteRequest) ([]string, error) {
+			var values []string
+			for _, branch := range branches {
+				if strings.HasPrefix(branch, request.Params.Argument.Value) {
+					values = append(values, branch)
+				}
+			}
+			return values, nil
+		},
+	)
+	server.AddResourceCompletion(
+		"projects://{projectId}/issues",
+		"projectId",
+		func(ctx context.Context, request mcp.CompleteRequest) ([]string, error) {
+			values := make([]string, 250)
+			for i := range values {
+				values[i] = fmt.Sprintf("project-%d", i)
+			}
+			return values, nil
+		},
+	)
+	server.AddPromptCompletion(
+		"broken",
+		"arg",
+		func(ctx context.Context, request mcp.CompleteRequest) ([]string, error) {
+			return nil, fmt.Errorf("backend unavailable")
+		},
+	)
+
+	tests := []struct {
+		name     string
+		message  string
+		validate func(t *testing.T, response mcp.JSONRPCMessage)
+	}{
+		{
+			name: "Prompt argument completion",
+			message: `{
+                "jsonrpc": "2.0",
+                "id": 1,
+                "method": "completion/complete",
+                "params": {
+                    "ref": {"type": "ref/prompt", "name": "code_review"},
+                    "argument": {"name": "branch", "value": "ma"}
+                }
+            }`,
+			validate: func(t *testing.T, response mcp.JSONRPCMessage) {
+				resp, ok := response.(mcp.JSONRPCResponse)
+				assert.True(t, ok)
+
+				result, ok := resp.Result.(mcp.CompleteResult)
+				assert.True(t, ok)
+				assert.Equal(t, []string{"main", "master"}, result.Completion.Values)
+				assert.Equal(t, 2, result.Completion.Total)
+				assert.False(t, result.Completion.HasMore)
+			},
+		},
+		{
+			name: "Resource template completion is capped",
+			message: `{
+                "jsonrpc": "2.0",
+                "id": 1,
+                "method": "completion/complete",
+                "params": {
+                    "ref": {"type": "ref/resource", "uri": "projects://{projectId}/issues"},
+                    "argument": {"name": "projectId", "value": ""}
+                }
+            }`,
+			validate: func(t *testing.T, response mcp.JSONRPCMessage) {
+				resp, ok := response.(mcp.JSONRPCResponse)
+				assert.True(t, ok)
+
+				result, ok := resp.Result.(mcp.CompleteResult)
+				assert.True(t, ok)
+				assert.Len(t, result.Completion.Values, maxCompletionValues)
+				assert.Equal(t, 250, result.Completion.Total)
+				assert.True(t, result.Completion.HasMore)
+			},
+		},
+		{
+			name: "No provider returns empty completion",
+			message: `{
+                "jsonrpc": "2.0",
+                "id": 1,
+                "method": "completion/complete",
+                "params": {
+                    "ref": {"type": "ref/prompt", "name": "code_review"},
+                    "argument": {"name": "unknown", "value": "x"}
+                }
+            }`,
+			validate: func(t *testing.T, response mcp.JSONRPCMessage) {
+				resp, ok := response.(mcp.JSONRPCResponse)
+				assert.True(t, ok)
+
+				result, ok := resp.Result.(mcp.CompleteResult)
+				assert.True(t, ok)
+				assert.NotNil(t, result.Completion.Values)
+				assert.Empty(t, result.Completion.Values)
+			},
+		},
+		{
+			name: "Unknown reference type",
+			message: `{
+                "jsonrpc": "2.0",
+                "id": 1,
+                "method": "completion/complete",
+                "params": {
+                    "ref": {"type": "ref/unknown", "name": "code_review"},
+                    "argument": {"name": "branch", "value": ""}
+                }
+            }`,
+			validate: func(t *testing.T, response mcp.JSONRPCMessage) {
+				errorResponse, ok := response.(mcp.JSONRPCError)
+				assert.True(t, ok)
+				assert.Equal(t, mcp.INVALID_PARAMS, errorResponse.Error.Code)
+			},
+		},
+		{
+			name: "Provider error",
+			message: `{
+                "jsonrpc": "2.0",
+                "id": 1,
+                "method": "completion/complete",
+                "params": {
+                    "ref": {"type": "ref/prompt", "name": "broken"},
+                    "argument": {"name": "arg", "value": ""}
+                }
+            }`,
+			validate: func(t *testing.T, response mcp.JSONRPCMessage) {
+				errorResponse, ok := response.(mcp.JSONRPCError)
+				assert.True(t, ok)
+				assert.Equal(t, mcp.INTERNAL_ERROR, errorResponse.Error.Code)
+				assert.Equal(t, "backend unavailable", errorResponse.Error.Message)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := server.HandleMessage(
+				context.Background(),
+				[]byte(tt.message),
+			)
+			tt.validate(t, response)
+		})
+	}
+}
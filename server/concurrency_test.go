@@ -0,0 +1,189 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shaneholloman/mcp-server-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func callToolMessage(id int, name string) []byte {
+	message, _ := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"method":  "tools/call",
+		"params": map[string]interface{}{
+			"name": name,
+		},
+	})
+	return message
+}
+
+func TestMCPServer_ConcurrentRegistrationAndDispatch(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0",
+		WithResourceCapabilities(true, true),
+		WithPromptCapabilities(true),
+	)
+	_, session := newTestSession(t, server, "session-1")
+	session.initialize(mcp.InitializeRequest{}, mcp.LATEST_PROTOCOL_VERSION)
+
+	toolHandler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText(request.Params.Name), nil
+	}
+	server.AddTool(mcp.NewTool("stable"), toolHandler)
+
+	const workers = 8
+	const iterations = 200
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+
+	// Drain list_changed notifications so the channel never fills up
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-session.Messages():
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var mutators sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		mutators.Add(1)
+		go func(w int) {
+			defer mutators.Done()
+			for i := 0; i < iterations; i++ {
+				name := fmt.Sprintf("tool-%d-%d", w, i%10)
+				server.AddTool(mcp.NewTool(name), toolHandler)
+				server.AddPrompt(mcp.NewPrompt(name), nil)
+				server.AddResource(
+					mcp.NewResource("test://"+name, name),
+					func(ctx context.Context, request mcp.ReadResourceRequest) ([]interface{}, error) {
+						return nil, nil
+					},
+				)
+				server.RemoveTool(name)
+			}
+		}(w)
+
+		mutators.Add(1)
+		go func(w int) {
+			defer mutators.Done()
+			for i := 0; i < iterations; i++ {
+				response := server.HandleMessage(ctx, callToolMessage(i, "stable"))
+				resp, ok := response.(mcp.JSONRPCResponse)
+				if assert.True(t, ok, "unexpected response: %v", response) {
+					result := resp.Result.(*mcp.CallToolResult)
+					assert.Equal(t, "stable", result.Content[0].(mcp.TextContent).Text)
+				}
+
+				for _, method := range []string{"tools/list", "prompts/list", "resources/list"} {
+					response := server.HandleMessage(ctx, listMessage(method, ""))
+					_, ok := response.(mcp.JSONRPCResponse)
+					assert.True(t, ok, "unexpected response: %v", response)
+				}
+			}
+		}(w)
+	}
+
+	mutators.Wait()
+	cancel()
+	wg.Wait()
+}
+
+func TestSSEServer_ConcurrentToolCalls(t *testing.T) {
+	mcpServer := NewMCPServer("test-server", "1.0.0")
+
+	release := make(chan struct{})
+	mcpServer.AddTool(
+		mcp.NewTool("slow"),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			<-release
+			return mcp.NewToolResultText("done"), nil
+		},
+	)
+
+	testServer := NewTestServer(mcpServer)
+	defer testServer.Close()
+
+	sseResp, err := http.Get(fmt.Sprintf("%s/sse", testServer.URL))
+	require.NoError(t, err)
+	defer sseResp.Body.Close()
+
+	events := make(chan string, 100)
+	go func() {
+		scanner := bufio.NewScanner(sseResp.Body)
+		for scanner.Scan() {
+			if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+				events <- data
+			}
+		}
+		close(events)
+	}()
+	messageURL := strings.TrimSpace(<-events)
+
+	resp, err := http.Post(
+		messageURL,
+		"application/json",
+		strings.NewReader(initializeMessage),
+	)
+	require.NoError(t, err)
+	resp.Body.Close()
+	<-events // initialize response
+
+	const calls = 20
+	var wg sync.WaitGroup
+	for i := 0; i < calls; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp, err := http.Post(
+				messageURL,
+				"application/json",
+				bytes.NewReader(callToolMessage(i, "slow")),
+			)
+			if assert.NoError(t, err) {
+				resp.Body.Close()
+			}
+		}(i)
+	}
+
+	// Churn the registry while the calls are in flight
+	for i := 0; i < 50; i++ {
+		name := fmt.Sprintf("churn-%d", i)
+		mcpServer.AddTool(mcp.NewTool(name), nil)
+		mcpServer.RemoveTool(name)
+	}
+	close(release)
+	wg.Wait()
+
+	responses := 0
+	timeout := time.After(5 * time.Second)
+	for responses < calls {
+		select {
+		case data := <-events:
+			// Every event must be a complete JSON-RPC message
+			var message map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(data), &message), data)
+			if _, ok := message["result"]; ok {
+				responses++
+			}
+		case <-timeout:
+			t.Fatalf("received %d of %d responses", responses, calls)
+		}
+	}
+}
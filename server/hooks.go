@@ -0,0 +1,214 @@
+package server
+
+import (
+	"context"
+	"runtime/debug"
+	"time"
+
+	"github.com/shaneholloman/mcp-server-go/mcp"
+)
+
+// Hooks are callbacks invoked as the server handles messages, for metrics,
+// tracing and audit logging. Any of them may be nil. They are called
+// synchronously from the goroutine handling the message, so they should
+// return quickly. A panic in a hook is recovered and reported like a panic
+// in a handler.
+type Hooks struct {
+	// OnInitialize is called when a session has been initialized, before
+	// the result is sent to the client
+	OnInitialize func(
+		ctx context.Context,
+		session *ClientSession,
+		request mcp.InitializeRequest,
+		result *mcp.InitializeResult,
+	)
+
+	// BeforeRequest is called when a request from the client is received
+	BeforeRequest func(ctx context.Context, method string, id interface{})
+
+	// AfterRequest is called once a request has been handled with either
+	// the result sent to the client or an *mcp.Error describing the error
+	// response. Requests cancelled by the client report context.Canceled.
+	AfterRequest func(
+		ctx context.Context,
+		method string,
+		id interface{},
+		result interface{},
+		err error,
+		duration time.Duration,
+	)
+
+	// OnNotificationSent is called when a notification has been queued for
+	// a session
+	OnNotificationSent func(
+		session *ClientSession,
+		notification mcp.JSONRPCNotification,
+	)
+
+	// OnSessionClosed is called when a session has been unregistered, e.g.
+	// because its client disconnected
+	OnSessionClosed func(session *ClientSession)
+
+	// OnPanic is called when a handler or another hook panics, with the
+	// panic value and the stack of the panicking goroutine. ID is nil for
+	// notifications.
+	OnPanic func(
+		ctx context.Context,
+		method string,
+		id interface{},
+		value interface{},
+		stack []byte,
+	)
+}
+
+// WithHooks installs hooks on the server. It may be given several times; the
+// hooks are invoked in the order they were installed.
+func WithHooks(hooks *Hooks) ServerOption {
+	return func(s *MCPServer) {
+		s.hooks = append(s.hooks, hooks)
+	}
+}
+
+func (s *MCPServer) onInitialize(
+	ctx context.Context,
+	session *ClientSession,
+	request mcp.InitializeRequest,
+	result *mcp.InitializeResult,
+) {
+	for _, hooks := range s.hooks {
+		if hooks.OnInitialize != nil {
+			s.callHook(ctx, "OnInitialize", "initialize", nil, func() {
+				hooks.OnInitialize(ctx, session, request, result)
+			})
+		}
+	}
+}
+
+func (s *MCPServer) beforeRequest(ctx context.Context, method string, id interface{}) {
+	for _, hooks := range s.hooks {
+		if hooks.BeforeRequest != nil {
+			s.callHook(ctx, "BeforeRequest", method, id, func() {
+				hooks.BeforeRequest(ctx, method, id)
+			})
+		}
+	}
+}
+
+func (s *MCPServer) afterRequest(
+	ctx context.Context,
+	method string,
+	id interface{},
+	response mcp.JSONRPCMessage,
+	cancelled bool,
+	duration time.Duration,
+) {
+	if len(s.hooks) == 0 {
+		return
+	}
+
+	var result interface{}
+	var err error
+	switch response := response.(type) {
+	case mcp.JSONRPCResponse:
+		result = response.Result
+	case mcp.JSONRPCError:
+		err = mcp.NewError(
+			response.Error.Code,
+			response.Error.Message,
+			response.Error.Data,
+		)
+	}
+	if cancelled {
+		result, err = nil, context.Canceled
+	}
+
+	for _, hooks := range s.hooks {
+		if hooks.AfterRequest != nil {
+			s.callHook(ctx, "AfterRequest", method, id, func() {
+				hooks.AfterRequest(ctx, method, id, result, err, duration)
+			})
+		}
+	}
+}
+
+func (s *MCPServer) onNotificationSent(
+	session *ClientSession,
+	notification mcp.JSONRPCNotification,
+) {
+	for _, hooks := range s.hooks {
+		if hooks.OnNotificationSent != nil {
+			ctx := s.WithContext(context.Background(), session)
+			s.callHook(ctx, "OnNotificationSent", notification.Method, nil, func() {
+				hooks.OnNotificationSent(session, notification)
+			})
+		}
+	}
+}
+
+func (s *MCPServer) onSessionClosed(session *ClientSession) {
+	for _, hooks := range s.hooks {
+		if hooks.OnSessionClosed != nil {
+			ctx := s.WithContext(context.Background(), session)
+			s.callHook(ctx, "OnSessionClosed", "", nil, func() {
+				hooks.OnSessionClosed(session)
+			})
+		}
+	}
+}
+
+func (s *MCPServer) onPanic(
+	ctx context.Context,
+	method string,
+	id interface{},
+	value interface{},
+	stack []byte,
+) bool {
+	handled := false
+	for _, hooks := range s.hooks {
+		if hooks.OnPanic != nil {
+			s.callOnPanic(hooks.OnPanic, ctx, method, id, value, stack)
+			handled = true
+		}
+	}
+	return handled
+}
+
+// callOnPanic calls an OnPanic hook, logging a panic in the hook itself
+// rather than reporting it again
+func (s *MCPServer) callOnPanic(
+	hook func(context.Context, string, interface{}, interface{}, []byte),
+	ctx context.Context,
+	method string,
+	id interface{},
+	value interface{},
+	stack []byte,
+) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.errLogger.Printf("panic in OnPanic hook: %v\n%s", r, debug.Stack())
+		}
+	}()
+	hook(ctx, method, id, value, stack)
+}
+
+// callHook calls a hook for the message with the given method and ID,
+// recovering from a panic in it so that the hook cannot take down the
+// goroutine handling the message. The panic is passed to the OnPanic hooks,
+// or logged if there are none.
+func (s *MCPServer) callHook(
+	ctx context.Context,
+	name string,
+	method string,
+	id interface{},
+	hook func(),
+) {
+	defer func() {
+		if r := recover(); r != nil {
+			stack := debug.Stack()
+			if !s.onPanic(ctx, method, id, r, stack) {
+				s.errLogger.Printf("panic in %s hook: %v\n%s", name, r, stack)
+			}
+		}
+	}()
+	hook()
+}
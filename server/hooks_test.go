@@ -0,0 +1,260 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shaneholloman/mcp-server-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hookRecorder collects the events reported through Hooks
+type hookRecorder struct {
+	mu     sync.Mutex
+	events []string
+	errs   []error
+}
+
+func (r *hookRecorder) record(format string, args ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, fmt.Sprintf(format, args...))
+}
+
+func (r *hookRecorder) snapshot() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string{}, r.events...)
+}
+
+func (r *hookRecorder) hooks(prefix string) *Hooks {
+	return &Hooks{
+		OnInitialize: func(
+			ctx context.Context,
+			session *ClientSession,
+			request mcp.InitializeRequest,
+			result *mcp.InitializeResult,
+		) {
+			r.record(
+				"%sinitialize %s %s %s",
+				prefix,
+				session.ID(),
+				request.Params.ClientInfo.Name,
+				result.ServerInfo.Name,
+			)
+		},
+		BeforeRequest: func(ctx context.Context, method string, id interface{}) {
+			r.record("%sbefore %s %v", prefix, method, id)
+		},
+		AfterRequest: func(
+			ctx context.Context,
+			method string,
+			id interface{},
+			result interface{},
+			err error,
+			duration time.Duration,
+		) {
+			r.record("%safter %s %v %t %v", prefix, method, id, result != nil, err)
+			r.mu.Lock()
+			r.errs = append(r.errs, err)
+			r.mu.Unlock()
+		},
+		OnNotificationSent: func(
+			session *ClientSession,
+			notification mcp.JSONRPCNotification,
+		) {
+			r.record("%snotification %s %s", prefix, session.ID(), notification.Method)
+		},
+		OnSessionClosed: func(session *ClientSession) {
+			r.record("%sclosed %s", prefix, session.ID())
+		},
+	}
+}
+
+func TestMCPServer_Hooks(t *testing.T) {
+	recorder := &hookRecorder{}
+	server := NewMCPServer("test-server", "1.0.0",
+		WithHooks(recorder.hooks("")),
+		WithHooks(&Hooks{}),
+	)
+	server.AddTool(
+		mcp.NewTool("notify"),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			err := ServerFromContext(ctx).SendNotificationToClient(ctx, "test/notify", nil)
+			return mcp.NewToolResultText("done"), err
+		},
+	)
+	ctx, _ := newTestSession(t, server, "session-1")
+
+	server.HandleMessage(ctx, []byte(initializeMessage))
+	server.HandleMessage(ctx, []byte(initializedMessage))
+	server.HandleMessage(ctx, callToolMessage(1, "notify"))
+	server.HandleMessage(ctx, callToolMessage(2, "unknown"))
+	server.UnregisterSession("session-1")
+
+	assert.Equal(t, []string{
+		"before initialize init",
+		"initialize session-1 test-client test-server",
+		"after initialize init true <nil>",
+		"before tools/call 1",
+		"notification session-1 test/notify",
+		"after tools/call 1 true <nil>",
+		"before tools/call 2",
+		"after tools/call 2 false Tool not found: unknown",
+		"closed session-1",
+	}, recorder.snapshot())
+}
+
+func TestMCPServer_HooksOrder(t *testing.T) {
+	recorder := &hookRecorder{}
+	server := NewMCPServer("test-server", "1.0.0",
+		WithHooks(recorder.hooks("first ")),
+		WithHooks(recorder.hooks("second ")),
+	)
+
+	server.HandleMessage(context.Background(), []byte(`{
+        "jsonrpc": "2.0",
+        "id": 1,
+        "method": "ping"
+    }`))
+
+	assert.Equal(t, []string{
+		"first before ping 1",
+		"second before ping 1",
+		"first after ping 1 true <nil>",
+		"second after ping 1 true <nil>",
+	}, recorder.snapshot())
+}
+
+func TestMCPServer_HooksCancelledRequest(t *testing.T) {
+	recorder := &hookRecorder{}
+	server, started, stopped := createBlockingToolServer()
+	server.hooks = append(server.hooks, recorder.hooks(""))
+	ctx, _ := newReadySession(t, server, "session-1")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		server.HandleMessage(ctx, callToolMessage(1, "block"))
+	}()
+	<-started
+	server.HandleMessage(ctx, cancelledMessage(1))
+	<-stopped
+	<-done
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	require.NotEmpty(t, recorder.errs)
+	assert.True(t, errors.Is(recorder.errs[len(recorder.errs)-1], context.Canceled))
+}
+
+func TestSSEServer_HooksSessionClosed(t *testing.T) {
+	recorder := &hookRecorder{}
+	mcpServer := NewMCPServer("test-server", "1.0.0",
+		WithHooks(recorder.hooks("")),
+	)
+	testServer := NewTestServer(mcpServer)
+	t.Cleanup(testServer.Close)
+
+	client := connectTestSSE(t, testServer.URL)
+	client.initialize(t)
+	testServer.CloseClientConnections()
+
+	assert.Eventually(t, func() bool {
+		events := recorder.snapshot()
+		return len(events) > 0 &&
+			events[len(events)-1] == "closed "+client.sessionID
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestMCPServer_PanickingHooks(t *testing.T) {
+	recorder := &hookRecorder{}
+	server := NewMCPServer("test-server", "1.0.0",
+		WithLogging(),
+		WithHooks(&Hooks{
+			BeforeRequest: func(ctx context.Context, method string, id interface{}) {
+				panic("before")
+			},
+			AfterRequest: func(
+				ctx context.Context,
+				method string,
+				id interface{},
+				result interface{},
+				err error,
+				duration time.Duration,
+			) {
+				panic("after")
+			},
+			OnNotificationSent: func(session *ClientSession, notification mcp.JSONRPCNotification) {
+				panic("notification")
+			},
+			OnSessionClosed: func(session *ClientSession) {
+				panic("closed")
+			},
+		}),
+		WithHooks(&Hooks{
+			OnPanic: func(
+				ctx context.Context,
+				method string,
+				id interface{},
+				value interface{},
+				stack []byte,
+			) {
+				recorder.record("%s %v: %v", method, id, value)
+			},
+		}),
+	)
+
+	ctx, session := newTestSession(t, server, "session-1")
+	response := server.HandleMessage(ctx, []byte(`{"jsonrpc": "2.0", "id": 1, "method": "ping"}`))
+	assert.IsType(t, mcp.JSONRPCResponse{}, response)
+
+	require.NoError(t, server.SendNotificationToSession(
+		session.ID(),
+		"notifications/message",
+		map[string]interface{}{"data": "hello"},
+	))
+	server.UnregisterSession(session.ID())
+
+	assert.Equal(t, []string{
+		"ping 1: before",
+		"ping 1: after",
+		"notifications/message <nil>: notification",
+		" <nil>: closed",
+	}, recorder.snapshot())
+}
+
+func TestMCPServer_PanickingOnPanicHook(t *testing.T) {
+	var logs bytes.Buffer
+	server := NewMCPServer("test-server", "1.0.0",
+		WithErrorLogger(log.New(&logs, "", 0)),
+		WithHooks(&Hooks{
+			BeforeRequest: func(ctx context.Context, method string, id interface{}) {
+				panic("before")
+			},
+			OnPanic: func(
+				ctx context.Context,
+				method string,
+				id interface{},
+				value interface{},
+				stack []byte,
+			) {
+				panic("on panic")
+			},
+		}),
+	)
+
+	response := server.HandleMessage(
+		context.Background(),
+		[]byte(`{"jsonrpc": "2.0", "id": 1, "method": "ping"}`),
+	)
+	assert.IsType(t, mcp.JSONRPCResponse{}, response)
+	assert.Contains(t, logs.String(), "panic in OnPanic hook: on panic")
+}
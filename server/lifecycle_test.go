@@ -0,0 +1,134 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/shaneholloman/mcp-server-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func initializeMessageWithVersion(version string) []byte {
+	return []byte(fmt.Sprintf(`{
+        "jsonrpc": "2.0",
+        "id": 1,
+        "method": "initialize",
+        "params": {
+            "protocolVersion": %q,
+            "capabilities": {},
+            "clientInfo": {"name": "test-client", "version": "1.0.0"}
+        }
+    }`, version))
+}
+
+func TestMCPServer_Lifecycle(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0")
+	server.AddTool(
+		mcp.NewTool("test-tool"),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return mcp.NewToolResultText("done"), nil
+		},
+	)
+	ctx, session := newTestSession(t, server, "session-1")
+	assert.Equal(t, SessionUninitialized, session.State())
+
+	// Only pings are served before initialization
+	response := server.HandleMessage(ctx, callToolMessage(1, "test-tool"))
+	errorResponse, ok := response.(mcp.JSONRPCError)
+	require.True(t, ok)
+	assert.Equal(t, mcp.INVALID_REQUEST, errorResponse.Error.Code)
+
+	response = server.HandleMessage(ctx, []byte(`{
+        "jsonrpc": "2.0",
+        "id": 2,
+        "method": "ping"
+    }`))
+	assert.IsType(t, mcp.JSONRPCResponse{}, response)
+
+	response = server.HandleMessage(ctx, []byte(initializeMessage))
+	assert.IsType(t, mcp.JSONRPCResponse{}, response)
+	assert.Equal(t, SessionInitializing, session.State())
+
+	// Requests are served as soon as the initialize request was answered
+	response = server.HandleMessage(ctx, callToolMessage(3, "test-tool"))
+	assert.IsType(t, mcp.JSONRPCResponse{}, response)
+
+	assert.Nil(t, server.HandleMessage(ctx, []byte(initializedMessage)))
+	assert.Equal(t, SessionReady, session.State())
+
+	// A session can only be initialized once
+	response = server.HandleMessage(ctx, []byte(initializeMessage))
+	errorResponse, ok = response.(mcp.JSONRPCError)
+	require.True(t, ok)
+	assert.Equal(t, mcp.INVALID_REQUEST, errorResponse.Error.Code)
+	assert.Equal(t, SessionReady, session.State())
+}
+
+func TestMCPServer_LifecycleDefaultSession(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0")
+	server.AddTool(
+		mcp.NewTool("test-tool"),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return mcp.NewToolResultText("done"), nil
+		},
+	)
+
+	// Callers without a session are not subject to the lifecycle
+	response := server.HandleMessage(context.Background(), callToolMessage(1, "test-tool"))
+	assert.IsType(t, mcp.JSONRPCResponse{}, response)
+
+	for i := 0; i < 2; i++ {
+		response = server.HandleMessage(context.Background(), []byte(initializeMessage))
+		assert.IsType(t, mcp.JSONRPCResponse{}, response)
+	}
+}
+
+func TestMCPServer_ProtocolVersionNegotiation(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []ServerOption
+		requested string
+		expected  string
+	}{
+		{
+			name:      "Supported version is echoed",
+			requested: mcp.LATEST_PROTOCOL_VERSION,
+			expected:  mcp.LATEST_PROTOCOL_VERSION,
+		},
+		{
+			name:      "Unsupported version gets the server's",
+			requested: "1999-01-01",
+			expected:  mcp.LATEST_PROTOCOL_VERSION,
+		},
+		{
+			name:      "Older configured version is echoed",
+			opts:      []ServerOption{WithProtocolVersions("2025-03-26", "2024-11-05")},
+			requested: "2024-11-05",
+			expected:  "2024-11-05",
+		},
+		{
+			name:      "Preferred configured version is offered",
+			opts:      []ServerOption{WithProtocolVersions("2025-03-26", "2024-11-05")},
+			requested: "2024-10-07",
+			expected:  "2025-03-26",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := NewMCPServer("test-server", "1.0.0", tt.opts...)
+			ctx, session := newTestSession(t, server, "session-1")
+
+			response := server.HandleMessage(ctx, initializeMessageWithVersion(tt.requested))
+			resp, ok := response.(mcp.JSONRPCResponse)
+			require.True(t, ok)
+			result, ok := resp.Result.(mcp.InitializeResult)
+			require.True(t, ok)
+
+			assert.Equal(t, tt.expected, result.ProtocolVersion)
+			assert.Equal(t, tt.expected, session.ProtocolVersion())
+		})
+	}
+}
@@ -0,0 +1,281 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/shaneholloman/mcp-server-go/mcp"
+)
+
+// ConcurrencyMode decides what happens to a tool call when the tool is
+// already running as many calls as its concurrency limit allows
+type ConcurrencyMode int
+
+const (
+	// QueueWhenBusy makes the call wait for a running call to finish, for as
+	// long as its context and the tool's timeout allow
+	QueueWhenBusy ConcurrencyMode = iota
+	// FailWhenBusy rejects the call at once with a CallToolResult that has
+	// IsError set
+	FailWhenBusy
+)
+
+// errToolTimeout is the cause of the context of a tool call whose own
+// timeout expired, as opposed to a deadline set by the caller
+var errToolTimeout = errors.New("tool timeout")
+
+// toolLimits holds the timeout and concurrency limit of a tool. Zero values
+// mean no limit.
+type toolLimits struct {
+	timeout       time.Duration
+	maxConcurrent int
+	mode          ConcurrencyMode
+}
+
+// WithToolTimeout sets a default timeout for tool calls, covering the time a
+// call waits for a concurrency slot as well as the time its handler runs.
+// Calls that time out get a CallToolResult with IsError set, while the
+// handler's context is cancelled. Tools can override it with ToolTimeout.
+func WithToolTimeout(timeout time.Duration) ServerOption {
+	return func(s *MCPServer) {
+		s.toolLimits.timeout = timeout
+	}
+}
+
+// WithToolConcurrency sets a default limit on the number of calls each tool
+// runs at once, and what happens to the calls beyond it. Tools can override
+// it with ToolConcurrency.
+func WithToolConcurrency(limit int, mode ConcurrencyMode) ServerOption {
+	return func(s *MCPServer) {
+		s.toolLimits.maxConcurrent = limit
+		s.toolLimits.mode = mode
+	}
+}
+
+// ToolTimeout sets the timeout of a single tool, overriding
+// WithToolTimeout. A timeout of zero disables it.
+func ToolTimeout(timeout time.Duration) ToolRegistrationOption {
+	return func(r *toolRegistration) {
+		r.timeout = &timeout
+	}
+}
+
+// ToolConcurrency limits the number of calls a single tool runs at once,
+// overriding WithToolConcurrency. A limit of zero disables it.
+func ToolConcurrency(limit int, mode ConcurrencyMode) ToolRegistrationOption {
+	return func(r *toolRegistration) {
+		r.maxConcurrent = &limit
+		r.mode = mode
+	}
+}
+
+// ToolStats counts the calls of a tool since it was registered
+type ToolStats struct {
+	// Calls is the number of calls received, including rejected ones
+	Calls int64
+	// Active is the number of calls whose handler is running
+	Active int64
+	// Queued is the number of calls waiting for a concurrency slot
+	Queued int64
+	// Rejected is the number of calls turned away because the tool was busy
+	Rejected int64
+	// TimedOut is the number of calls that ran out of time
+	TimedOut int64
+	// Failed is the number of calls whose handler returned an error or a
+	// result with IsError set
+	Failed int64
+}
+
+// ToolStats returns the statistics of every registered tool by name
+func (s *MCPServer) ToolStats() map[string]ToolStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	stats := make(map[string]ToolStats, len(s.tools))
+	for name, entry := range s.tools {
+		stats[name] = entry.limiter.stats()
+	}
+	return stats
+}
+
+// toolLimiter enforces the limits of a tool and keeps its statistics
+type toolLimiter struct {
+	limits toolLimits
+	slots  chan struct{}
+	// reportPanic reports a panic in a handler that no caller is left to
+	// recover, because the call timed out before the handler panicked
+	reportPanic func(ctx context.Context, value interface{}, stack []byte)
+
+	calls    atomic.Int64
+	active   atomic.Int64
+	queued   atomic.Int64
+	rejected atomic.Int64
+	timedOut atomic.Int64
+	failed   atomic.Int64
+}
+
+func newToolLimiter(
+	limits toolLimits,
+	reportPanic func(ctx context.Context, value interface{}, stack []byte),
+) *toolLimiter {
+	l := &toolLimiter{limits: limits, reportPanic: reportPanic}
+	if limits.maxConcurrent > 0 {
+		l.slots = make(chan struct{}, limits.maxConcurrent)
+	}
+	return l
+}
+
+func (l *toolLimiter) stats() ToolStats {
+	return ToolStats{
+		Calls:    l.calls.Load(),
+		Active:   l.active.Load(),
+		Queued:   l.queued.Load(),
+		Rejected: l.rejected.Load(),
+		TimedOut: l.timedOut.Load(),
+		Failed:   l.failed.Load(),
+	}
+}
+
+// wrap returns a handler that calls next within the limits of the tool
+func (l *toolLimiter) wrap(name string, next ToolHandlerFunc) ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		l.calls.Add(1)
+		if l.limits.timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeoutCause(ctx, l.limits.timeout, errToolTimeout)
+			defer cancel()
+		}
+
+		if l.slots != nil {
+			select {
+			case l.slots <- struct{}{}:
+			default:
+				if l.limits.mode == FailWhenBusy {
+					l.rejected.Add(1)
+					return mcp.NewToolResultError(fmt.Sprintf(
+						"Tool %s is busy, try again later",
+						name,
+					)), nil
+				}
+				if err := l.wait(ctx); err != nil {
+					return l.expired(ctx, name)
+				}
+			}
+		}
+
+		result, err := l.run(ctx, next, request)
+		if err != nil && ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+			return l.expired(ctx, name)
+		}
+		if err != nil || (result != nil && result.IsError) {
+			l.failed.Add(1)
+		}
+		return result, err
+	}
+}
+
+// wait blocks until a concurrency slot is free or ctx is done
+func (l *toolLimiter) wait(ctx context.Context) error {
+	l.queued.Add(1)
+	defer l.queued.Add(-1)
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run calls next holding a concurrency slot, if the tool has any, which is
+// released once next returns. With a timeout, next runs in its own
+// goroutine so the call can return when time runs out even if next ignores
+// its context. A panic in next is then raised again in the caller, carrying
+// the stack where it happened, or reported by the limiter if the caller has
+// already given up on the call.
+func (l *toolLimiter) run(
+	ctx context.Context,
+	next ToolHandlerFunc,
+	request mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	l.active.Add(1)
+	done := func() {
+		l.active.Add(-1)
+		if l.slots != nil {
+			<-l.slots
+		}
+	}
+
+	if l.limits.timeout <= 0 {
+		defer done()
+		return next(ctx, request)
+	}
+
+	type outcome struct {
+		result *mcp.CallToolResult
+		err    error
+		panic  *handlerPanic
+	}
+	outcomes := make(chan outcome, 1)
+	var mu sync.Mutex
+	abandoned := false // set once the caller no longer waits for an outcome
+	go func() {
+		defer done()
+		defer func() {
+			if r := recover(); r != nil {
+				p := &handlerPanic{value: r, stack: debug.Stack()}
+				mu.Lock()
+				defer mu.Unlock()
+				if abandoned {
+					if l.reportPanic != nil {
+						l.reportPanic(ctx, p.value, p.stack)
+					}
+					return
+				}
+				outcomes <- outcome{panic: p}
+			}
+		}()
+		result, err := next(ctx, request)
+		outcomes <- outcome{result: result, err: err}
+	}()
+
+	var o outcome
+	select {
+	case o = <-outcomes:
+	case <-ctx.Done():
+		mu.Lock()
+		abandoned = true
+		mu.Unlock()
+		// The handler may have panicked just as the context was done
+		select {
+		case o = <-outcomes:
+		default:
+		}
+	}
+	if o.panic != nil {
+		panic(o.panic)
+	}
+	// Once the context is done, what the handler returned, often an error
+	// reacting to it, is superseded by the context's error
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return o.result, o.err
+}
+
+// expired reports a call that ran out of time, or returns the error of a
+// context cancelled or timed out by the caller
+func (l *toolLimiter) expired(ctx context.Context, name string) (*mcp.CallToolResult, error) {
+	if context.Cause(ctx) != errToolTimeout {
+		return nil, ctx.Err()
+	}
+	l.timedOut.Add(1)
+	return mcp.NewToolResultError(fmt.Sprintf(
+		"Tool %s timed out after %v",
+		name,
+		l.limits.timeout,
+	)), nil
+}
@@ -0,0 +1,301 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shaneholloman/mcp-server-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// callTool calls a tool through HandleMessage and returns its result
+func callTool(t *testing.T, server *MCPServer, id int, name string) *mcp.CallToolResult {
+	t.Helper()
+	response := server.HandleMessage(context.Background(), callToolMessage(id, name))
+	resp, ok := response.(mcp.JSONRPCResponse)
+	require.True(t, ok, "unexpected response %v", response)
+	result, ok := resp.Result.(*mcp.CallToolResult)
+	require.True(t, ok)
+	return result
+}
+
+func resultText(t *testing.T, result *mcp.CallToolResult) string {
+	t.Helper()
+	require.Len(t, result.Content, 1)
+	return result.Content[0].(mcp.TextContent).Text
+}
+
+func TestMCPServer_ToolTimeout(t *testing.T) {
+	tests := []struct {
+		name         string
+		serverOpts   []ServerOption
+		toolOpts     []ToolRegistrationOption
+		expectedText string
+		timedOut     bool
+	}{
+		{
+			name:         "Server default",
+			serverOpts:   []ServerOption{WithToolTimeout(20 * time.Millisecond)},
+			expectedText: "Tool slow timed out after 20ms",
+			timedOut:     true,
+		},
+		{
+			name:         "Tool override",
+			serverOpts:   []ServerOption{WithToolTimeout(time.Hour)},
+			toolOpts:     []ToolRegistrationOption{ToolTimeout(20 * time.Millisecond)},
+			expectedText: "Tool slow timed out after 20ms",
+			timedOut:     true,
+		},
+		{
+			name:         "Tool disables server default",
+			serverOpts:   []ServerOption{WithToolTimeout(20 * time.Millisecond)},
+			toolOpts:     []ToolRegistrationOption{ToolTimeout(0)},
+			expectedText: "done",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := NewMCPServer("test-server", "1.0.0", tt.serverOpts...)
+			release := make(chan struct{})
+			server.AddTool(
+				mcp.NewTool("slow"),
+				func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+					// Ignores its context, so the call must not wait for it
+					<-release
+					return mcp.NewToolResultText("done"), nil
+				},
+				tt.toolOpts...,
+			)
+			if !tt.timedOut {
+				close(release)
+			}
+
+			result := callTool(t, server, 1, "slow")
+			assert.Equal(t, tt.timedOut, result.IsError)
+			assert.Equal(t, tt.expectedText, resultText(t, result))
+
+			stats := server.ToolStats()["slow"]
+			assert.Equal(t, int64(1), stats.Calls)
+			if tt.timedOut {
+				assert.Equal(t, int64(1), stats.TimedOut)
+				close(release)
+				assert.Eventually(t, func() bool {
+					return server.ToolStats()["slow"].Active == 0
+				}, time.Second, 5*time.Millisecond)
+			}
+		})
+	}
+}
+
+func TestMCPServer_ToolTimeoutCancelsContext(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0")
+	cancelled := make(chan error, 1)
+	server.AddTool(
+		mcp.NewTool("slow"),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			<-ctx.Done()
+			cancelled <- ctx.Err()
+			return nil, ctx.Err()
+		},
+		ToolTimeout(10*time.Millisecond),
+	)
+
+	result := callTool(t, server, 1, "slow")
+	assert.True(t, result.IsError)
+	assert.ErrorIs(t, <-cancelled, context.DeadlineExceeded)
+}
+
+// createBusyToolServer returns a server with a tool whose calls block until
+// released, and a channel receiving a value as each call starts
+func createBusyToolServer(
+	opts ...ToolRegistrationOption,
+) (*MCPServer, chan struct{}, chan struct{}) {
+	server := NewMCPServer("test-server", "1.0.0")
+	started := make(chan struct{}, 10)
+	release := make(chan struct{})
+	server.AddTool(
+		mcp.NewTool("busy"),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			started <- struct{}{}
+			<-release
+			return mcp.NewToolResultText("done"), nil
+		},
+		opts...,
+	)
+	return server, started, release
+}
+
+func TestMCPServer_ToolConcurrencyFailFast(t *testing.T) {
+	server, started, release := createBusyToolServer(ToolConcurrency(1, FailWhenBusy))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		assert.False(t, callTool(t, server, 1, "busy").IsError)
+	}()
+	<-started
+
+	result := callTool(t, server, 2, "busy")
+	assert.True(t, result.IsError)
+	assert.Equal(t, "Tool busy is busy, try again later", resultText(t, result))
+
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, ToolStats{Calls: 2, Rejected: 1}, server.ToolStats()["busy"])
+}
+
+func TestMCPServer_ToolConcurrencyQueue(t *testing.T) {
+	server, started, release := createBusyToolServer(ToolConcurrency(2, QueueWhenBusy))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			assert.False(t, callTool(t, server, id, "busy").IsError)
+		}(i)
+	}
+	<-started
+	<-started
+
+	assert.Eventually(t, func() bool {
+		stats := server.ToolStats()["busy"]
+		return stats.Active == 2 && stats.Queued == 1
+	}, time.Second, 5*time.Millisecond)
+
+	close(release)
+	wg.Wait()
+	<-started
+
+	assert.Equal(t, ToolStats{Calls: 3}, server.ToolStats()["busy"])
+}
+
+func TestMCPServer_ToolConcurrencyQueueTimeout(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0",
+		WithToolConcurrency(1, QueueWhenBusy),
+		WithToolTimeout(20*time.Millisecond),
+	)
+	release := make(chan struct{})
+	defer close(release)
+	server.AddTool(
+		mcp.NewTool("busy"),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			<-release
+			return mcp.NewToolResultText("done"), nil
+		},
+	)
+
+	// The first call holds the only slot beyond its own timeout, so the
+	// second times out while queued
+	first := callTool(t, server, 1, "busy")
+	assert.True(t, first.IsError)
+	second := callTool(t, server, 2, "busy")
+	assert.True(t, second.IsError)
+
+	stats := server.ToolStats()["busy"]
+	assert.Equal(t, int64(2), stats.Calls)
+	assert.Equal(t, int64(2), stats.TimedOut)
+}
+
+func TestMCPServer_ToolStatsFailures(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0")
+	server.AddTool(
+		mcp.NewTool("failing"),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return mcp.NewToolResultError("backend unavailable"), nil
+		},
+	)
+
+	for i := 0; i < 3; i++ {
+		callTool(t, server, i, "failing")
+	}
+	assert.Equal(t, ToolStats{Calls: 3, Failed: 3}, server.ToolStats()["failing"])
+
+	server.RemoveTool("failing")
+	assert.NotContains(t, server.ToolStats(), "failing")
+}
+
+// panickingTool is a tool handler that panics, so that tests can look for it
+// in the stack of the panic
+func panickingTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	panic("boom")
+}
+
+func TestMCPServer_ToolTimeoutPanics(t *testing.T) {
+	type report struct {
+		id    interface{}
+		value interface{}
+		stack string
+	}
+	reports := make(chan report, 1)
+	release := make(chan struct{})
+	server := NewMCPServer("test-server", "1.0.0",
+		WithToolTimeout(time.Hour),
+		WithHooks(&Hooks{
+			OnPanic: func(
+				ctx context.Context,
+				method string,
+				id interface{},
+				value interface{},
+				stack []byte,
+			) {
+				reports <- report{id: id, value: value, stack: string(stack)}
+			},
+		}),
+	)
+	server.AddTool(mcp.NewTool("panics"), panickingTool)
+	server.AddTool(
+		mcp.NewTool("late"),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			<-release
+			return panickingTool(ctx, request)
+		},
+		ToolTimeout(10*time.Millisecond),
+	)
+
+	// The panic is reported with the stack of the handler's goroutine
+	response := server.HandleMessage(context.Background(), callToolMessage(1, "panics"))
+	assert.IsType(t, mcp.JSONRPCError{}, response)
+	r := <-reports
+	assert.Equal(t, "boom", r.value)
+	assert.Contains(t, r.stack, "panickingTool")
+
+	// A panic after the call timed out is still reported
+	result := callTool(t, server, 2, "late")
+	assert.Equal(t, "Tool late timed out after 10ms", resultText(t, result))
+	close(release)
+	select {
+	case r = <-reports:
+		assert.Equal(t, "boom", r.value)
+		assert.EqualValues(t, 2, r.id)
+		assert.Contains(t, r.stack, "panickingTool")
+	case <-time.After(time.Second):
+		t.Fatal("late panic not reported")
+	}
+}
+
+func TestMCPServer_ToolCallerDeadline(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0")
+	server.AddTool(
+		mcp.NewTool("slow"),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+		ToolTimeout(time.Hour),
+	)
+
+	// A deadline set by the caller is not the tool timing out
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	response := server.HandleMessage(ctx, callToolMessage(1, "slow"))
+	_, ok := response.(mcp.JSONRPCResponse)
+	assert.False(t, ok, "unexpected response %v", response)
+	assert.Equal(t, int64(0), server.ToolStats()["slow"].TimedOut)
+}
@@ -0,0 +1,254 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/shaneholloman/mcp-server-go/mcp"
+)
+
+// defaultLoggingLevel is the minimum level sent to clients that have not
+// issued a logging/setLevel request.
+const defaultLoggingLevel = mcp.LoggingLevelInfo
+
+// loggingLevelSeverity orders the logging levels by increasing severity, as
+// specified in RFC 5424.
+var loggingLevelSeverity = map[mcp.LoggingLevel]int{
+	mcp.LoggingLevelDebug:     0,
+	mcp.LoggingLevelInfo:      1,
+	mcp.LoggingLevelNotice:    2,
+	mcp.LoggingLevelWarning:   3,
+	mcp.LoggingLevelError:     4,
+	mcp.LoggingLevelCritical:  5,
+	mcp.LoggingLevelAlert:     6,
+	mcp.LoggingLevelEmergency: 7,
+}
+
+// logEnabled reports whether a message at the given level should be sent to
+// the client associated with ctx
+func (s *MCPServer) logEnabled(ctx context.Context, level mcp.LoggingLevel) bool {
+	if !s.capabilities.logging {
+		return false
+	}
+	minLevel := s.sessionFromContext(ctx).LogLevel()
+	return loggingLevelSeverity[level] >= loggingLevelSeverity[minLevel]
+}
+
+// Log sends a notifications/message log entry to the client associated with
+// ctx. Messages below the level the client requested via logging/setLevel are
+// silently dropped. Returns an error if logging is not enabled on the server
+// or the level is unknown.
+func (s *MCPServer) Log(
+	ctx context.Context,
+	level mcp.LoggingLevel,
+	logger string,
+	data interface{},
+) error {
+	if !s.capabilities.logging {
+		return fmt.Errorf("logging capability not enabled")
+	}
+	if _, ok := loggingLevelSeverity[level]; !ok {
+		return fmt.Errorf("invalid logging level: %q", level)
+	}
+	if !s.logEnabled(ctx, level) {
+		return nil
+	}
+
+	params := map[string]interface{}{
+		"level": level,
+		"data":  data,
+	}
+	if logger != "" {
+		params["logger"] = logger
+	}
+	return s.sendNotification(
+		s.sessionFromContext(ctx),
+		"notifications/message",
+		params,
+	)
+}
+
+func (s *MCPServer) handleSetLevel(
+	ctx context.Context,
+	id interface{},
+	request mcp.SetLevelRequest,
+) mcp.JSONRPCMessage {
+	level := request.Params.Level
+	if _, ok := loggingLevelSeverity[level]; !ok {
+		return createErrorResponse(
+			id,
+			mcp.INVALID_PARAMS,
+			fmt.Sprintf("Invalid logging level: %s", level),
+		)
+	}
+
+	ClientSessionFromContext(ctx).setLogLevel(level)
+	return createResponse(id, mcp.EmptyResult{})
+}
+
+// LogHandler is a slog.Handler that forwards log records to the MCP client
+// associated with the context passed to the slog call, as
+// notifications/message entries.
+type LogHandler struct {
+	server *MCPServer
+	logger string
+	attrs  []groupedAttr
+	groups []string
+}
+
+// groupedAttr is an attribute added through WithAttrs, along with the groups
+// that were open when it was added
+type groupedAttr struct {
+	groups []string
+	attr   slog.Attr
+}
+
+var _ slog.Handler = (*LogHandler)(nil)
+
+// NewLogHandler creates a slog.Handler that sends records to MCP clients
+// through the server, using logger as the logger name of every entry. Use the
+// context-aware slog functions (e.g. slog.InfoContext) with the context given
+// to a handler so records reach the right client.
+func NewLogHandler(server *MCPServer, logger string) *LogHandler {
+	return &LogHandler{
+		server: server,
+		logger: logger,
+	}
+}
+
+// Enabled reports whether the client associated with ctx wants records at level
+func (h *LogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.server.logEnabled(ctx, slogLevelToLoggingLevel(level))
+}
+
+// Handle sends the record to the client as a structured log entry, with
+// the record's time unless it is zero
+func (h *LogHandler) Handle(ctx context.Context, record slog.Record) error {
+	data := map[string]interface{}{
+		"message": record.Message,
+	}
+	if !record.Time.IsZero() {
+		data["time"] = record.Time
+	}
+
+	for _, grouped := range h.attrs {
+		fields := make(map[string]interface{})
+		addSlogAttr(fields, grouped.attr)
+		mergeFields(data, grouped.groups, fields)
+	}
+	fields := make(map[string]interface{})
+	record.Attrs(func(attr slog.Attr) bool {
+		addSlogAttr(fields, attr)
+		return true
+	})
+	mergeFields(data, h.groups, fields)
+
+	return h.server.Log(
+		ctx,
+		slogLevelToLoggingLevel(record.Level),
+		h.logger,
+		data,
+	)
+}
+
+// WithAttrs returns a handler that includes attrs in every record
+func (h *LogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append([]groupedAttr{}, h.attrs...)
+	for _, attr := range attrs {
+		clone.attrs = append(clone.attrs, groupedAttr{
+			groups: h.groups,
+			attr:   attr,
+		})
+	}
+	return &clone
+}
+
+// WithGroup returns a handler that nests subsequent attributes under name
+func (h *LogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.groups = append(append([]string{}, h.groups...), name)
+	return &clone
+}
+
+// groupFields returns the nested map for the given group path, creating it
+// as needed
+func groupFields(fields map[string]interface{}, groups []string) map[string]interface{} {
+	for _, group := range groups {
+		nested, ok := fields[group].(map[string]interface{})
+		if !ok {
+			nested = make(map[string]interface{})
+			fields[group] = nested
+		}
+		fields = nested
+	}
+	return fields
+}
+
+// mergeFields adds fields to data under the given group path. Groups are
+// only created for fields to put in them, as slog drops empty groups.
+func mergeFields(data map[string]interface{}, groups []string, fields map[string]interface{}) {
+	if len(fields) == 0 {
+		return
+	}
+	target := groupFields(data, groups)
+	for key, value := range fields {
+		target[key] = value
+	}
+}
+
+// addSlogAttr adds a resolved slog attribute to fields, expanding groups and
+// dropping those without attributes
+func addSlogAttr(fields map[string]interface{}, attr slog.Attr) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return
+	}
+
+	if attr.Value.Kind() == slog.KindGroup {
+		if attr.Key == "" {
+			for _, groupAttr := range attr.Value.Group() {
+				addSlogAttr(fields, groupAttr)
+			}
+			return
+		}
+		group := make(map[string]interface{})
+		for _, groupAttr := range attr.Value.Group() {
+			addSlogAttr(group, groupAttr)
+		}
+		if len(group) > 0 {
+			fields[attr.Key] = group
+		}
+		return
+	}
+
+	if err, ok := attr.Value.Any().(error); ok {
+		fields[attr.Key] = err.Error()
+		return
+	}
+	fields[attr.Key] = attr.Value.Any()
+}
+
+// slogLevelToLoggingLevel maps a slog level onto the closest MCP logging level
+func slogLevelToLoggingLevel(level slog.Level) mcp.LoggingLevel {
+	switch {
+	case level < slog.LevelInfo:
+		return mcp.LoggingLevelDebug
+	case level < slog.LevelWarn:
+		return mcp.LoggingLevelInfo
+	case level < slog.LevelError:
+		return mcp.LoggingLevelWarning
+	case level < slog.LevelError+4:
+		return mcp.LoggingLevelError
+	case level < slog.LevelError+8:
+		return mcp.LoggingLevelCritical
+	case level < slog.LevelError+12:
+		return mcp.LoggingLevelAlert
+	default:
+		return mcp.LoggingLevelEmergency
+	}
+}
@@ -0,0 +1,221 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"testing/slogtest"
+	"time"
+
+	"github.com/shaneholloman/mcp-server-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// drainNotifications returns the notifications queued for the session so far
+func drainNotifications(session *ClientSession) []mcp.JSONRPCNotification {
+	var notifications []mcp.JSONRPCNotification
+	for {
+		select {
+		case message := <-session.Messages():
+			if notification, ok := message.(mcp.JSONRPCNotification); ok {
+				notifications = append(notifications, notification)
+			}
+		default:
+			return notifications
+		}
+	}
+}
+
+func TestMCPServer_SetLevel(t *testing.T) {
+	tests := []struct {
+		name        string
+		options     []ServerOption
+		message     string
+		expectedErr int
+	}{
+		{
+			name:    "Valid level",
+			options: []ServerOption{WithLogging()},
+			message: `{
+                "jsonrpc": "2.0",
+                "id": 1,
+                "method": "logging/setLevel",
+                "params": {"level": "warning"}
+            }`,
+		},
+		{
+			name:    "Invalid level",
+			options: []ServerOption{WithLogging()},
+			message: `{
+                "jsonrpc": "2.0",
+                "id": 1,
+                "method": "logging/setLevel",
+                "params": {"level": "verbose"}
+            }`,
+			expectedErr: mcp.INVALID_PARAMS,
+		},
+		{
+			name: "Logging not enabled",
+			message: `{
+                "jsonrpc": "2.0",
+                "id": 1,
+                "method": "logging/setLevel",
+                "params": {"level": "warning"}
+            }`,
+			expectedErr: mcp.METHOD_NOT_FOUND,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := NewMCPServer("test-server", "1.0.0", tt.options...)
+			response := server.HandleMessage(
+				context.Background(),
+				[]byte(tt.message),
+			)
+
+			if tt.expectedErr != 0 {
+				errorResponse, ok := response.(mcp.JSONRPCError)
+				assert.True(t, ok)
+				assert.Equal(t, tt.expectedErr, errorResponse.Error.Code)
+				return
+			}
+
+			resp, ok := response.(mcp.JSONRPCResponse)
+			assert.True(t, ok)
+			_, ok = resp.Result.(mcp.EmptyResult)
+			assert.True(t, ok)
+		})
+	}
+}
+
+func TestMCPServer_Log(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0", WithLogging())
+
+	ctx1, session1 := newReadySession(t, server, "session-1")
+	ctx2, session2 := newReadySession(t, server, "session-2")
+
+	response := server.HandleMessage(ctx1, []byte(`{
+        "jsonrpc": "2.0",
+        "id": 1,
+        "method": "logging/setLevel",
+        "params": {"level": "error"}
+    }`))
+	_, ok := response.(mcp.JSONRPCResponse)
+	require.True(t, ok)
+
+	// Session 1 asked for error and above
+	require.NoError(t, server.Log(ctx1, mcp.LoggingLevelWarning, "db", "dropped"))
+	require.NoError(t, server.Log(ctx1, mcp.LoggingLevelCritical, "db", "sent"))
+	// Session 2 uses the default level
+	require.NoError(t, server.Log(ctx2, mcp.LoggingLevelDebug, "", "dropped"))
+	require.NoError(t, server.Log(ctx2, mcp.LoggingLevelInfo, "", "sent"))
+
+	notifications := drainNotifications(session1)
+	require.Len(t, notifications, 1)
+	assert.Equal(t, mcp.LoggingLevelError, session1.LogLevel())
+	assert.Equal(t, "notifications/message", notifications[0].Method)
+	params := notifications[0].Params.AdditionalFields
+	assert.Equal(t, mcp.LoggingLevelCritical, params["level"])
+	assert.Equal(t, "db", params["logger"])
+	assert.Equal(t, "sent", params["data"])
+
+	notifications = drainNotifications(session2)
+	require.Len(t, notifications, 1)
+	assert.Equal(t, defaultLoggingLevel, session2.LogLevel())
+	params = notifications[0].Params.AdditionalFields
+	assert.Equal(t, mcp.LoggingLevelInfo, params["level"])
+	assert.NotContains(t, params, "logger")
+
+	assert.Error(t, server.Log(ctx1, mcp.LoggingLevel("verbose"), "", "x"))
+}
+
+func TestMCPServer_LogWithoutLogging(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0")
+	err := server.Log(context.Background(), mcp.LoggingLevelError, "", "x")
+	assert.Error(t, err)
+	assert.Empty(t, drainNotifications(server.defaultSession))
+}
+
+func TestLogHandler(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0", WithLogging())
+	ctx, session := newTestSession(t, server, "session-1")
+
+	logger := slog.New(NewLogHandler(server, "app")).
+		With("service", "billing").
+		WithGroup("request")
+
+	logger.DebugContext(ctx, "dropped")
+	logger.WarnContext(ctx, "slow query",
+		"table", "invoices",
+		"err", errors.New("timeout"),
+	)
+
+	notifications := drainNotifications(session)
+	require.Len(t, notifications, 1)
+
+	params := notifications[0].Params.AdditionalFields
+	assert.Equal(t, mcp.LoggingLevelWarning, params["level"])
+	assert.Equal(t, "app", params["logger"])
+	data := params["data"].(map[string]interface{})
+	assert.IsType(t, time.Time{}, data["time"])
+	delete(data, "time")
+	assert.Equal(t, map[string]interface{}{
+		"message": "slow query",
+		"service": "billing",
+		"request": map[string]interface{}{
+			"table": "invoices",
+			"err":   "timeout",
+		},
+	}, params["data"])
+}
+
+func TestLogHandler_SlogContract(t *testing.T) {
+	var session *ClientSession
+	slogtest.Run(
+		t,
+		func(t *testing.T) slog.Handler {
+			server := NewMCPServer("test-server", "1.0.0", WithLogging())
+			var ctx context.Context
+			ctx, session = newTestSession(t, server, "session-1")
+			return contextHandler{Handler: NewLogHandler(server, "app"), ctx: ctx}
+		},
+		func(t *testing.T) map[string]any {
+			notifications := drainNotifications(session)
+			require.Len(t, notifications, 1)
+			params := notifications[0].Params.AdditionalFields
+			// The entry's level and message are where slogtest expects them
+			// in other handlers' output
+			result := params["data"].(map[string]interface{})
+			result[slog.LevelKey] = params["level"]
+			result[slog.MessageKey] = result["message"]
+			delete(result, "message")
+			return result
+		},
+	)
+}
+
+// contextHandler is a slog.Handler that handles every record with ctx,
+// since slogtest logs without a context
+type contextHandler struct {
+	slog.Handler
+	ctx context.Context
+}
+
+func (h contextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.Handler.Enabled(h.ctx, level)
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	return h.Handler.Handle(h.ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithAttrs(attrs), ctx: h.ctx}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithGroup(name), ctx: h.ctx}
+}
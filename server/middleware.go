@@ -0,0 +1,329 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/shaneholloman/mcp-server-go/mcp"
+)
+
+// ToolMiddleware wraps a tool handler with additional behavior
+type ToolMiddleware func(next ToolHandlerFunc) ToolHandlerFunc
+
+// PromptMiddleware wraps a prompt handler with additional behavior
+type PromptMiddleware func(next PromptHandlerFunc) PromptHandlerFunc
+
+// ResourceMiddleware wraps a resource handler with additional behavior. It
+// applies to both resources and resource templates.
+type ResourceMiddleware func(next ResourceHandlerFunc) ResourceHandlerFunc
+
+// WithToolMiddleware installs middleware around every tool handler. The
+// first middleware is the outermost, and server middleware runs before any
+// registered with UseToolMiddleware.
+func WithToolMiddleware(middleware ...ToolMiddleware) ServerOption {
+	return func(s *MCPServer) {
+		s.toolMiddleware = append(s.toolMiddleware, middleware...)
+	}
+}
+
+// WithPromptMiddleware installs middleware around every prompt handler. The
+// first middleware is the outermost, and server middleware runs before any
+// registered with UsePromptMiddleware.
+func WithPromptMiddleware(middleware ...PromptMiddleware) ServerOption {
+	return func(s *MCPServer) {
+		s.promptMiddleware = append(s.promptMiddleware, middleware...)
+	}
+}
+
+// WithResourceMiddleware installs middleware around every resource and
+// resource template handler. The first middleware is the outermost, and
+// server middleware runs before any registered with UseResourceMiddleware.
+func WithResourceMiddleware(middleware ...ResourceMiddleware) ServerOption {
+	return func(s *MCPServer) {
+		s.resourceMiddleware = append(s.resourceMiddleware, middleware...)
+	}
+}
+
+// toolRegistration collects the options given when registering a tool
+type toolRegistration struct {
+	middleware    []ToolMiddleware
+	validate      *bool
+	timeout       *time.Duration
+	maxConcurrent *int
+	mode          ConcurrencyMode
+	cache         *cacheOptions
+}
+
+// ToolRegistrationOption configures a single tool registration
+type ToolRegistrationOption func(*toolRegistration)
+
+// UseToolMiddleware installs middleware around the handler of a single tool
+func UseToolMiddleware(middleware ...ToolMiddleware) ToolRegistrationOption {
+	return func(r *toolRegistration) {
+		r.middleware = append(r.middleware, middleware...)
+	}
+}
+
+// promptRegistration collects the options given when registering a prompt
+type promptRegistration struct {
+	middleware []PromptMiddleware
+}
+
+// PromptRegistrationOption configures a single prompt registration
+type PromptRegistrationOption func(*promptRegistration)
+
+// UsePromptMiddleware installs middleware around the handler of a single
+// prompt
+func UsePromptMiddleware(middleware ...PromptMiddleware) PromptRegistrationOption {
+	return func(r *promptRegistration) {
+		r.middleware = append(r.middleware, middleware...)
+	}
+}
+
+// resourceRegistration collects the options given when registering a
+// resource or resource template
+type resourceRegistration struct {
+	middleware []ResourceMiddleware
+	cache      *cacheOptions
+}
+
+// ResourceRegistrationOption configures a single resource or resource
+// template registration
+type ResourceRegistrationOption func(*resourceRegistration)
+
+// UseResourceMiddleware installs middleware around the handler of a single
+// resource or resource template
+func UseResourceMiddleware(middleware ...ResourceMiddleware) ResourceRegistrationOption {
+	return func(r *resourceRegistration) {
+		r.middleware = append(r.middleware, middleware...)
+	}
+}
+
+// newToolEntry wraps handler in the server's and the registration's
+// middleware, with the tool's cache innermost and argument validation around
+// it when they are enabled, and the tool's limits outermost. The cache hands
+// out copies, so middleware may change the results it returns.
+func (s *MCPServer) newToolEntry(
+	tool mcp.Tool,
+	handler ToolHandlerFunc,
+	opts []ToolRegistrationOption,
+) toolEntry {
+	var registration toolRegistration
+	for _, opt := range opts {
+		opt(&registration)
+	}
+	var cache *resultCache
+	if registration.cache != nil {
+		cache = newResultCache(*registration.cache)
+		handler = cacheToolHandler(cache, handler)
+	}
+	validate := s.validateToolArguments
+	if registration.validate != nil {
+		validate = *registration.validate
+	}
+	if validate {
+		handler = validateArguments(tool, handler)
+	}
+	middleware := append(
+		append([]ToolMiddleware{}, s.toolMiddleware...),
+		registration.middleware...,
+	)
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+
+	limits := s.toolLimits
+	if registration.timeout != nil {
+		limits.timeout = *registration.timeout
+	}
+	if registration.maxConcurrent != nil {
+		limits.maxConcurrent = *registration.maxConcurrent
+		limits.mode = registration.mode
+	}
+	limiter := newToolLimiter(limits, func(ctx context.Context, value interface{}, stack []byte) {
+		s.reportPanic(ctx, "tools/call", requestIDFromContext(ctx), value, stack)
+	})
+	return toolEntry{
+		tool:    tool,
+		handler: limiter.wrap(tool.Name, handler),
+		limiter: limiter,
+		cache:   cache,
+	}
+}
+
+// newPromptEntry wraps handler in the server's and the registration's
+// middleware
+func (s *MCPServer) newPromptEntry(
+	prompt mcp.Prompt,
+	handler PromptHandlerFunc,
+	opts []PromptRegistrationOption,
+) promptEntry {
+	var registration promptRegistration
+	for _, opt := range opts {
+		opt(&registration)
+	}
+	middleware := append(
+		append([]PromptMiddleware{}, s.promptMiddleware...),
+		registration.middleware...,
+	)
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+	return promptEntry{prompt: prompt, handler: handler}
+}
+
+// wrapResourceHandler wraps handler in the registration's cache, if it has
+// one, and in the server's and the registration's middleware
+func (s *MCPServer) wrapResourceHandler(
+	handler ResourceHandlerFunc,
+	opts []ResourceRegistrationOption,
+) (ResourceHandlerFunc, *resultCache) {
+	var registration resourceRegistration
+	for _, opt := range opts {
+		opt(&registration)
+	}
+	var cache *resultCache
+	if registration.cache != nil {
+		cache = newResultCache(*registration.cache)
+		handler = cacheResourceHandler(cache, handler)
+	}
+	middleware := append(
+		append([]ResourceMiddleware{}, s.resourceMiddleware...),
+		registration.middleware...,
+	)
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+	return handler, cache
+}
+
+// newResourceEntry wraps handler in the registration's cache and in the
+// server's and the registration's middleware
+func (s *MCPServer) newResourceEntry(
+	resource mcp.Resource,
+	handler ResourceHandlerFunc,
+	opts []ResourceRegistrationOption,
+) resourceEntry {
+	handler, cache := s.wrapResourceHandler(handler, opts)
+	return resourceEntry{
+		resource: resource,
+		handler:  handler,
+		cache:    cache,
+	}
+}
+
+// newResourceTemplateEntry parses the URI template and wraps handler in the
+// registration's cache and in the server's and the registration's
+// middleware. Invalid templates are logged and are still listed, but never
+// match a URI.
+func (s *MCPServer) newResourceTemplateEntry(
+	template mcp.ResourceTemplate,
+	handler ResourceTemplateHandlerFunc,
+	opts []ResourceRegistrationOption,
+) resourceTemplateEntry {
+	uriTemplate, err := mcp.ParseURITemplate(template.URITemplate)
+	if err != nil {
+		s.errLogger.Printf("resource template %s: %v", template.Name, err)
+	}
+	wrapped, cache := s.wrapResourceHandler(ResourceHandlerFunc(handler), opts)
+	return resourceTemplateEntry{
+		template:    template,
+		uriTemplate: uriTemplate,
+		handler:     ResourceTemplateHandlerFunc(wrapped),
+		cache:       cache,
+	}
+}
+
+// ToolRecoveryMiddleware turns a panic in a tool handler into an error
+func ToolRecoveryMiddleware() ToolMiddleware {
+	return func(next ToolHandlerFunc) ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (result *mcp.CallToolResult, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					result = nil
+					err = fmt.Errorf("panic in tool %s: %v", request.Params.Name, r)
+				}
+			}()
+			return next(ctx, request)
+		}
+	}
+}
+
+// PromptRecoveryMiddleware turns a panic in a prompt handler into an error
+func PromptRecoveryMiddleware() PromptMiddleware {
+	return func(next PromptHandlerFunc) PromptHandlerFunc {
+		return func(ctx context.Context, request mcp.GetPromptRequest) (result *mcp.GetPromptResult, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					result = nil
+					err = fmt.Errorf("panic in prompt %s: %v", request.Params.Name, r)
+				}
+			}()
+			return next(ctx, request)
+		}
+	}
+}
+
+// ResourceRecoveryMiddleware turns a panic in a resource handler into an
+// error
+func ResourceRecoveryMiddleware() ResourceMiddleware {
+	return func(next ResourceHandlerFunc) ResourceHandlerFunc {
+		return func(ctx context.Context, request mcp.ReadResourceRequest) (contents []interface{}, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					contents = nil
+					err = fmt.Errorf("panic in resource %s: %v", request.Params.URI, r)
+				}
+			}()
+			return next(ctx, request)
+		}
+	}
+}
+
+// TimingFunc receives the duration of a handler invocation. Method is the
+// request method, e.g. "tools/call", and name identifies the tool, prompt or
+// resource URI.
+type TimingFunc func(
+	ctx context.Context,
+	method string,
+	name string,
+	elapsed time.Duration,
+	err error,
+)
+
+// ToolTimingMiddleware reports how long each tool call takes
+func ToolTimingMiddleware(observe TimingFunc) ToolMiddleware {
+	return func(next ToolHandlerFunc) ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			start := time.Now()
+			result, err := next(ctx, request)
+			observe(ctx, "tools/call", request.Params.Name, time.Since(start), err)
+			return result, err
+		}
+	}
+}
+
+// PromptTimingMiddleware reports how long each prompt takes to render
+func PromptTimingMiddleware(observe TimingFunc) PromptMiddleware {
+	return func(next PromptHandlerFunc) PromptHandlerFunc {
+		return func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+			start := time.Now()
+			result, err := next(ctx, request)
+			observe(ctx, "prompts/get", request.Params.Name, time.Since(start), err)
+			return result, err
+		}
+	}
+}
+
+// ResourceTimingMiddleware reports how long each resource read takes
+func ResourceTimingMiddleware(observe TimingFunc) ResourceMiddleware {
+	return func(next ResourceHandlerFunc) ResourceHandlerFunc {
+		return func(ctx context.Context, request mcp.ReadResourceRequest) ([]interface{}, error) {
+			start := time.Now()
+			contents, err := next(ctx, request)
+			observe(ctx, "resources/read", request.Params.URI, time.Since(start), err)
+			return contents, err
+		}
+	}
+}
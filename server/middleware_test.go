@@ -0,0 +1,313 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shaneholloman/mcp-server-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tracer records the order in which middleware and handlers run
+type tracer struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (tr *tracer) record(call string) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.calls = append(tr.calls, call)
+}
+
+func (tr *tracer) tool(name string) ToolMiddleware {
+	return func(next ToolHandlerFunc) ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			tr.record(name)
+			return next(ctx, request)
+		}
+	}
+}
+
+func (tr *tracer) prompt(name string) PromptMiddleware {
+	return func(next PromptHandlerFunc) PromptHandlerFunc {
+		return func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+			tr.record(name)
+			return next(ctx, request)
+		}
+	}
+}
+
+func (tr *tracer) resource(name string) ResourceMiddleware {
+	return func(next ResourceHandlerFunc) ResourceHandlerFunc {
+		return func(ctx context.Context, request mcp.ReadResourceRequest) ([]interface{}, error) {
+			tr.record(name)
+			return next(ctx, request)
+		}
+	}
+}
+
+func TestMCPServer_Middleware(t *testing.T) {
+	tr := &tracer{}
+	server := NewMCPServer("test-server", "1.0.0",
+		WithResourceCapabilities(false, false),
+		WithPromptCapabilities(false),
+		WithToolMiddleware(tr.tool("server-1"), tr.tool("server-2")),
+		WithPromptMiddleware(tr.prompt("server")),
+		WithResourceMiddleware(tr.resource("server")),
+	)
+
+	toolHandler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		tr.record("handler")
+		return mcp.NewToolResultText("done"), nil
+	}
+	server.AddTool(mcp.NewTool("plain"), toolHandler)
+	server.AddTool(
+		mcp.NewTool("wrapped"),
+		toolHandler,
+		UseToolMiddleware(tr.tool("tool-1")),
+		UseToolMiddleware(tr.tool("tool-2")),
+	)
+	server.AddPrompt(
+		mcp.Prompt{Name: "greeting"},
+		func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+			tr.record("handler")
+			return &mcp.GetPromptResult{}, nil
+		},
+		UsePromptMiddleware(tr.prompt("prompt")),
+	)
+	server.AddResource(
+		mcp.NewResource("test://static", "Static"),
+		func(ctx context.Context, request mcp.ReadResourceRequest) ([]interface{}, error) {
+			tr.record("handler")
+			return []interface{}{}, nil
+		},
+		UseResourceMiddleware(tr.resource("resource")),
+	)
+	server.AddResourceTemplate(
+		mcp.NewResourceTemplate("test://items/{id}", "Item"),
+		func(ctx context.Context, request mcp.ReadResourceRequest) ([]interface{}, error) {
+			tr.record("handler")
+			return []interface{}{}, nil
+		},
+		UseResourceMiddleware(tr.resource("template")),
+	)
+
+	tests := []struct {
+		name     string
+		message  string
+		expected []string
+	}{
+		{
+			name:     "Tool with server middleware",
+			message:  string(callToolMessage(1, "plain")),
+			expected: []string{"server-1", "server-2", "handler"},
+		},
+		{
+			name:    "Tool with registration middleware",
+			message: string(callToolMessage(1, "wrapped")),
+			expected: []string{
+				"server-1",
+				"server-2",
+				"tool-1",
+				"tool-2",
+				"handler",
+			},
+		},
+		{
+			name: "Prompt",
+			message: `{
+                "jsonrpc": "2.0",
+                "id": 1,
+                "method": "prompts/get",
+                "params": {"name": "greeting"}
+            }`,
+			expected: []string{"server", "prompt", "handler"},
+		},
+		{
+			name: "Resource",
+			message: `{
+                "jsonrpc": "2.0",
+                "id": 1,
+                "method": "resources/read",
+                "params": {"uri": "test://static"}
+            }`,
+			expected: []string{"server", "resource", "handler"},
+		},
+		{
+			name: "Resource template",
+			message: `{
+                "jsonrpc": "2.0",
+                "id": 1,
+                "method": "resources/read",
+                "params": {"uri": "test://items/42"}
+            }`,
+			expected: []string{"server", "template", "handler"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr.calls = nil
+			response := server.HandleMessage(context.Background(), []byte(tt.message))
+			assert.IsType(t, mcp.JSONRPCResponse{}, response)
+			assert.Equal(t, tt.expected, tr.calls)
+		})
+	}
+}
+
+func TestMCPServer_MiddlewareShortCircuit(t *testing.T) {
+	deny := func(next ToolHandlerFunc) ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return nil, errors.New("access denied")
+		}
+	}
+	called := false
+	server := NewMCPServer("test-server", "1.0.0")
+	server.SetTools(ServerTool{
+		Tool: mcp.NewTool("secret"),
+		Handler: func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			called = true
+			return mcp.NewToolResultText("secret"), nil
+		},
+		Options: []ToolRegistrationOption{UseToolMiddleware(deny)},
+	})
+
+	response := server.HandleMessage(context.Background(), callToolMessage(1, "secret"))
+	errorResponse, ok := response.(mcp.JSONRPCError)
+	require.True(t, ok)
+	assert.Equal(t, "access denied", errorResponse.Error.Message)
+	assert.False(t, called)
+}
+
+func TestRecoveryMiddleware(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0",
+		WithResourceCapabilities(false, false),
+		WithPromptCapabilities(false),
+		WithToolMiddleware(ToolRecoveryMiddleware()),
+		WithPromptMiddleware(PromptRecoveryMiddleware()),
+		WithResourceMiddleware(ResourceRecoveryMiddleware()),
+	)
+	server.AddTool(
+		mcp.NewTool("panic"),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			panic("boom")
+		},
+	)
+	server.AddPrompt(
+		mcp.Prompt{Name: "panic"},
+		func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+			panic("boom")
+		},
+	)
+	server.AddResource(
+		mcp.NewResource("test://panic", "Panic"),
+		func(ctx context.Context, request mcp.ReadResourceRequest) ([]interface{}, error) {
+			panic("boom")
+		},
+	)
+
+	tests := []struct {
+		name     string
+		message  string
+		expected string
+	}{
+		{
+			name:     "Tool",
+			message:  string(callToolMessage(1, "panic")),
+			expected: "panic in tool panic: boom",
+		},
+		{
+			name: "Prompt",
+			message: `{
+                "jsonrpc": "2.0",
+                "id": 1,
+                "method": "prompts/get",
+                "params": {"name": "panic"}
+            }`,
+			expected: "panic in prompt panic: boom",
+		},
+		{
+			name: "Resource",
+			message: `{
+                "jsonrpc": "2.0",
+                "id": 1,
+                "method": "resources/read",
+                "params": {"uri": "test://panic"}
+            }`,
+			expected: "panic in resource test://panic: boom",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := server.HandleMessage(context.Background(), []byte(tt.message))
+			errorResponse, ok := response.(mcp.JSONRPCError)
+			require.True(t, ok)
+			assert.Equal(t, mcp.INTERNAL_ERROR, errorResponse.Error.Code)
+			assert.Equal(t, tt.expected, errorResponse.Error.Message)
+		})
+	}
+}
+
+func TestTimingMiddleware(t *testing.T) {
+	var observed []string
+	observe := func(ctx context.Context, method, name string, elapsed time.Duration, err error) {
+		assert.GreaterOrEqual(t, elapsed, 10*time.Millisecond)
+		observed = append(observed, fmt.Sprintf("%s %s %v", method, name, err))
+	}
+
+	server := NewMCPServer("test-server", "1.0.0",
+		WithResourceCapabilities(false, false),
+		WithPromptCapabilities(false),
+		WithToolMiddleware(ToolTimingMiddleware(observe)),
+		WithPromptMiddleware(PromptTimingMiddleware(observe)),
+		WithResourceMiddleware(ResourceTimingMiddleware(observe)),
+	)
+	server.AddTool(
+		mcp.NewTool("slow"),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			time.Sleep(10 * time.Millisecond)
+			return nil, errors.New("failed")
+		},
+	)
+	server.AddPrompt(
+		mcp.Prompt{Name: "slow"},
+		func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+			time.Sleep(10 * time.Millisecond)
+			return &mcp.GetPromptResult{}, nil
+		},
+	)
+	server.AddResource(
+		mcp.NewResource("test://slow", "Slow"),
+		func(ctx context.Context, request mcp.ReadResourceRequest) ([]interface{}, error) {
+			time.Sleep(10 * time.Millisecond)
+			return []interface{}{}, nil
+		},
+	)
+
+	server.HandleMessage(context.Background(), callToolMessage(1, "slow"))
+	server.HandleMessage(context.Background(), []byte(`{
+        "jsonrpc": "2.0",
+        "id": 2,
+        "method": "prompts/get",
+        "params": {"name": "slow"}
+    }`))
+	server.HandleMessage(context.Background(), []byte(`{
+        "jsonrpc": "2.0",
+        "id": 3,
+        "method": "resources/read",
+        "params": {"uri": "test://slow"}
+    }`))
+
+	assert.Equal(t, []string{
+		"tools/call slow failed",
+		"prompts/get slow <nil>",
+		"resources/read test://slow <nil>",
+	}, observed)
+}
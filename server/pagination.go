@@ -0,0 +1,86 @@
+package server
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/shaneholloman/mcp-server-go/mcp"
+)
+
+// WithPageSize sets the maximum number of items returned by a single
+// resources/list, resources/templates/list, prompts/list or tools/list
+// request. Clients fetch the remaining items by passing back the nextCursor
+// of the previous page. A size of zero, the default, disables pagination.
+func WithPageSize(size int) ServerOption {
+	return func(s *MCPServer) {
+		if size < 0 {
+			size = 0
+		}
+		s.pageSize = size
+	}
+}
+
+// cursorVersion prefixes every cursor so that the format can change later
+// without misreading cursors handed out before
+const cursorVersion = "v1"
+
+// encodeCursor returns an opaque cursor into the named list pointing after
+// the item with the given key
+func encodeCursor(list, key string) mcp.Cursor {
+	return mcp.Cursor(base64.StdEncoding.EncodeToString(
+		[]byte(cursorVersion + ":" + list + ":" + key),
+	))
+}
+
+// decodeCursor returns the key of the last item of the previous page,
+// rejecting cursors that were not issued for the named list
+func decodeCursor(list string, cursor mcp.Cursor) (string, error) {
+	decoded, err := base64.StdEncoding.DecodeString(string(cursor))
+	if err != nil {
+		return "", fmt.Errorf("invalid cursor: %q", cursor)
+	}
+	key, ok := strings.CutPrefix(string(decoded), cursorVersion+":"+list+":")
+	if !ok {
+		return "", fmt.Errorf("invalid cursor: %q", cursor)
+	}
+	return key, nil
+}
+
+// paginate sorts the items of the named list by key and returns the page
+// that follows cursor, along with the cursor of the next page, if any.
+//
+// Cursors encode the key of the last item returned rather than an offset, so
+// a page boundary stays put when items are registered or removed between
+// requests: the next page always starts at the first key after the cursor.
+func paginate[T any](
+	list string,
+	items []T,
+	key func(T) string,
+	cursor mcp.Cursor,
+	pageSize int,
+) ([]T, mcp.Cursor, error) {
+	sort.Slice(items, func(i, j int) bool {
+		return key(items[i]) < key(items[j])
+	})
+
+	start := 0
+	if cursor != "" {
+		after, err := decodeCursor(list, cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		start = sort.Search(len(items), func(i int) bool {
+			return key(items[i]) > after
+		})
+	}
+
+	items = items[start:]
+	if pageSize == 0 || len(items) <= pageSize {
+		return items, "", nil
+	}
+
+	page := items[:pageSize]
+	return page, encodeCursor(list, key(page[len(page)-1])), nil
+}
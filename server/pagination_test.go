@@ -0,0 +1,239 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/shaneholloman/mcp-server-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func listMessage(method string, cursor mcp.Cursor) []byte {
+	request := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  method,
+	}
+	if cursor != "" {
+		request["params"] = map[string]interface{}{"cursor": cursor}
+	}
+	message, _ := json.Marshal(request)
+	return message
+}
+
+func TestMCPServer_Pagination(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0",
+		WithResourceCapabilities(false, true),
+		WithPromptCapabilities(true),
+		WithPageSize(2),
+	)
+
+	resourceHandler := func(ctx context.Context, request mcp.ReadResourceRequest) ([]interface{}, error) {
+		return nil, nil
+	}
+	promptHandler := func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		return nil, nil
+	}
+	toolHandler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return nil, nil
+	}
+
+	// Registered out of order to check that pages are sorted
+	for _, i := range []int{3, 1, 4, 0, 2} {
+		server.AddResource(
+			mcp.NewResource(fmt.Sprintf("test://resource/%d", i), "Resource"),
+			resourceHandler,
+		)
+		server.AddResourceTemplate(
+			mcp.NewResourceTemplate(fmt.Sprintf("test://template/%d/{id}", i), "Template"),
+			resourceHandler,
+		)
+		server.AddPrompt(mcp.NewPrompt(fmt.Sprintf("prompt-%d", i)), promptHandler)
+		server.AddTool(mcp.NewTool(fmt.Sprintf("tool-%d", i)), toolHandler)
+	}
+
+	tests := []struct {
+		method string
+		names  func(result interface{}) ([]string, mcp.Cursor)
+		prefix string
+	}{
+		{
+			method: "resources/list",
+			prefix: "test://resource/",
+			names: func(result interface{}) ([]string, mcp.Cursor) {
+				r := result.(mcp.ListResourcesResult)
+				var names []string
+				for _, resource := range r.Resources {
+					names = append(names, resource.URI)
+				}
+				return names, r.NextCursor
+			},
+		},
+		{
+			method: "resources/templates/list",
+			prefix: "test://template/",
+			names: func(result interface{}) ([]string, mcp.Cursor) {
+				r := result.(mcp.ListResourceTemplatesResult)
+				var names []string
+				for _, template := range r.ResourceTemplates {
+					names = append(names, template.URITemplate[:len("test://template/0")])
+				}
+				return names, r.NextCursor
+			},
+		},
+		{
+			method: "prompts/list",
+			prefix: "prompt-",
+			names: func(result interface{}) ([]string, mcp.Cursor) {
+				r := result.(mcp.ListPromptsResult)
+				var names []string
+				for _, prompt := range r.Prompts {
+					names = append(names, prompt.Name)
+				}
+				return names, r.NextCursor
+			},
+		},
+		{
+			method: "tools/list",
+			prefix: "tool-",
+			names: func(result interface{}) ([]string, mcp.Cursor) {
+				r := result.(mcp.ListToolsResult)
+				var names []string
+				for _, tool := range r.Tools {
+					names = append(names, tool.Name)
+				}
+				return names, r.NextCursor
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			var pages [][]string
+			cursor := mcp.Cursor("")
+			for {
+				response := server.HandleMessage(
+					context.Background(),
+					listMessage(tt.method, cursor),
+				)
+				resp, ok := response.(mcp.JSONRPCResponse)
+				require.True(t, ok, "unexpected response: %v", response)
+
+				names, next := tt.names(resp.Result)
+				pages = append(pages, names)
+				if next == "" {
+					break
+				}
+				cursor = next
+			}
+
+			p := func(i int) string { return fmt.Sprintf("%s%d", tt.prefix, i) }
+			assert.Equal(t, [][]string{
+				{p(0), p(1)},
+				{p(2), p(3)},
+				{p(4)},
+			}, pages)
+		})
+	}
+}
+
+func TestMCPServer_PaginationSurvivesRegistrationChanges(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0", WithPageSize(2))
+	toolHandler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return nil, nil
+	}
+	for _, name := range []string{"b", "d", "f", "h"} {
+		server.AddTool(mcp.NewTool(name), toolHandler)
+	}
+
+	listTools := func(cursor mcp.Cursor) ([]string, mcp.Cursor) {
+		response := server.HandleMessage(
+			context.Background(),
+			listMessage("tools/list", cursor),
+		)
+		resp, ok := response.(mcp.JSONRPCResponse)
+		require.True(t, ok)
+		result := resp.Result.(mcp.ListToolsResult)
+		var names []string
+		for _, tool := range result.Tools {
+			names = append(names, tool.Name)
+		}
+		return names, result.NextCursor
+	}
+
+	names, cursor := listTools("")
+	assert.Equal(t, []string{"b", "d"}, names)
+
+	// Tools registered before the cursor position do not shift the next page
+	server.AddTool(mcp.NewTool("a"), toolHandler)
+	server.AddTool(mcp.NewTool("c"), toolHandler)
+	server.AddTool(mcp.NewTool("e"), toolHandler)
+
+	names, cursor = listTools(cursor)
+	assert.Equal(t, []string{"e", "f"}, names)
+
+	names, cursor = listTools(cursor)
+	assert.Equal(t, []string{"h"}, names)
+	assert.Equal(t, mcp.Cursor(""), cursor)
+}
+
+func TestMCPServer_PaginationRejectsForeignCursors(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0",
+		WithPromptCapabilities(true),
+		WithPageSize(1),
+	)
+	toolHandler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return nil, nil
+	}
+	promptHandler := func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		return nil, nil
+	}
+	for _, name := range []string{"a", "b"} {
+		server.AddTool(mcp.NewTool(name), toolHandler)
+		server.AddPrompt(mcp.NewPrompt(name), promptHandler)
+	}
+
+	response := server.HandleMessage(context.Background(), listMessage("tools/list", ""))
+	resp, ok := response.(mcp.JSONRPCResponse)
+	require.True(t, ok)
+	toolsCursor := resp.Result.(mcp.ListToolsResult).NextCursor
+	require.NotEmpty(t, toolsCursor)
+
+	tests := []struct {
+		name   string
+		method string
+		cursor mcp.Cursor
+	}{
+		{
+			name:   "Base64 without a version",
+			method: "tools/list",
+			cursor: "abcd",
+		},
+		{
+			name:   "Cursor of another list",
+			method: "prompts/list",
+			cursor: toolsCursor,
+		},
+		{
+			name:   "Unknown version",
+			method: "tools/list",
+			cursor: mcp.Cursor(base64.StdEncoding.EncodeToString([]byte("v0:tools:a"))),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := server.HandleMessage(
+				context.Background(),
+				listMessage(tt.method, tt.cursor),
+			)
+			errorResponse, ok := response.(mcp.JSONRPCError)
+			require.True(t, ok, "unexpected response: %v", response)
+			assert.Equal(t, mcp.INVALID_PARAMS, errorResponse.Error.Code)
+		})
+	}
+}
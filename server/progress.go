@@ -0,0 +1,178 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/shaneholloman/mcp-server-go/mcp"
+)
+
+// defaultProgressInterval is the minimum time between two progress
+// notifications for the same request unless configured otherwise
+const defaultProgressInterval = 100 * time.Millisecond
+
+// ErrProgressNotIncreasing is returned by ProgressReporter.Report when the
+// reported progress does not exceed the previously reported value
+var ErrProgressNotIncreasing = errors.New("progress must increase with every report")
+
+// WithProgressInterval sets the minimum time between two progress
+// notifications for the same request. Reports arriving sooner are held back,
+// except the one completing the request: the latest of them is sent once the
+// interval ends, or before the response if the request finishes first. Zero
+// disables rate limiting.
+func WithProgressInterval(interval time.Duration) ServerOption {
+	return func(s *MCPServer) {
+		s.progressInterval = interval
+	}
+}
+
+// ProgressReporter sends notifications/progress updates for the request
+// being handled. It is safe for concurrent use. Reporting is a no-op if the
+// client did not ask for progress by sending a progress token.
+type ProgressReporter struct {
+	server   *MCPServer
+	session  *ClientSession
+	token    mcp.ProgressToken
+	interval time.Duration
+
+	mu       sync.Mutex // held while sending, so reports go out in order
+	reported bool
+	last     float64
+	lastSent time.Time
+	pending  map[string]interface{} // latest report held back by the interval
+	timer    *time.Timer            // sends pending when the interval ends
+	finished bool                   // set once the response is on its way
+}
+
+// progressKey is the context key for storing the progress reporter
+type progressKey struct{}
+
+// ProgressReporterFromContext returns the progress reporter of the request
+// being handled. It never returns nil; outside a request, or when the client
+// sent no progress token, the returned reporter does nothing.
+func ProgressReporterFromContext(ctx context.Context) *ProgressReporter {
+	if reporter, ok := ctx.Value(progressKey{}).(*ProgressReporter); ok {
+		return reporter
+	}
+	return &ProgressReporter{}
+}
+
+// withProgress attaches a progress reporter for the request message to ctx
+func (s *MCPServer) withProgress(
+	ctx context.Context,
+	message json.RawMessage,
+) context.Context {
+	var request struct {
+		Params struct {
+			Meta struct {
+				ProgressToken mcp.ProgressToken `json:"progressToken"`
+			} `json:"_meta"`
+		} `json:"params"`
+	}
+	// Malformed params are reported by the method handler itself
+	_ = json.Unmarshal(message, &request)
+
+	return context.WithValue(ctx, progressKey{}, &ProgressReporter{
+		server:   s,
+		session:  ClientSessionFromContext(ctx),
+		token:    request.Params.Meta.ProgressToken,
+		interval: s.progressInterval,
+	})
+}
+
+// Enabled reports whether the client asked for progress updates
+func (p *ProgressReporter) Enabled() bool {
+	return p != nil && p.token != nil && p.session != nil
+}
+
+// Report notifies the client of the progress made so far. Total is the
+// progress required to complete the request, or zero if unknown, and message
+// optionally describes the current step.
+//
+// Progress must increase with every call. Reports following the previous one
+// more closely than the server's progress interval are held back, unless
+// they complete the request, and only the latest of them is sent once the
+// interval ends. Reports made after the request was handled are dropped.
+func (p *ProgressReporter) Report(progress, total float64, message string) error {
+	if !p.Enabled() {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.finished {
+		return nil
+	}
+	if p.reported && progress <= p.last {
+		return ErrProgressNotIncreasing
+	}
+	p.reported = true
+	p.last = progress
+
+	params := map[string]interface{}{
+		"progressToken": p.token,
+		"progress":      progress,
+	}
+	if total > 0 {
+		params["total"] = total
+	}
+	if message != "" {
+		params["message"] = message
+	}
+
+	complete := total > 0 && progress >= total
+	wait := p.interval - time.Since(p.lastSent)
+	if !complete && !p.lastSent.IsZero() && wait > 0 {
+		p.pending = params
+		if p.timer == nil {
+			p.timer = time.AfterFunc(wait, p.sendPending)
+		}
+		return nil
+	}
+	return p.send(params)
+}
+
+// sendPending sends the report held back by the interval, if any
+func (p *ProgressReporter) sendPending() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.timer = nil
+	if p.pending == nil || p.finished {
+		return
+	}
+	if err := p.send(p.pending); err != nil {
+		p.server.errLogger.Printf("Error sending progress: %v", err)
+	}
+}
+
+// finish sends the report held back by the interval, if any, once the
+// request has been handled and before its response goes out. Later reports
+// are dropped.
+func (p *ProgressReporter) finish() {
+	if !p.Enabled() {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.finished = true
+	if p.timer != nil {
+		p.timer.Stop()
+		p.timer = nil
+	}
+	if p.pending != nil {
+		if err := p.send(p.pending); err != nil {
+			p.server.errLogger.Printf("Error sending progress: %v", err)
+		}
+	}
+}
+
+// send sends a progress notification, replacing any report held back. The
+// caller must hold p.mu.
+func (p *ProgressReporter) send(params map[string]interface{}) error {
+	p.pending = nil
+	p.lastSent = time.Now()
+	return p.server.sendNotification(p.session, "notifications/progress", params)
+}
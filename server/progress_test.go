@@ -0,0 +1,224 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shaneholloman/mcp-server-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type progressReport struct {
+	progress float64
+	total    float64
+	message  string
+}
+
+// createProgressServer creates a server with a tool that makes the given
+// reports and returns their errors
+func createProgressServer(
+	reports []progressReport,
+	opts ...ServerOption,
+) (*MCPServer, chan []error) {
+	errs := make(chan []error, 1)
+	server := NewMCPServer("test-server", "1.0.0", opts...)
+	server.AddTool(
+		mcp.NewTool("progress"),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			progress := ProgressReporterFromContext(ctx)
+			var results []error
+			for _, report := range reports {
+				results = append(
+					results,
+					progress.Report(report.progress, report.total, report.message),
+				)
+			}
+			errs <- results
+			return mcp.NewToolResultText("done"), nil
+		},
+	)
+	return server, errs
+}
+
+func TestProgressReporter_Report(t *testing.T) {
+	tests := []struct {
+		name     string
+		message  string
+		opts     []ServerOption
+		reports  []progressReport
+		errs     []error
+		expected []map[string]interface{}
+	}{
+		{
+			name: "Without progress token",
+			message: `{
+                "jsonrpc": "2.0",
+                "id": 1,
+                "method": "tools/call",
+                "params": {"name": "progress"}
+            }`,
+			reports: []progressReport{{progress: 1, total: 2}},
+			errs:    []error{nil},
+		},
+		{
+			name: "With progress token",
+			message: `{
+                "jsonrpc": "2.0",
+                "id": 1,
+                "method": "tools/call",
+                "params": {"name": "progress", "_meta": {"progressToken": "abc"}}
+            }`,
+			opts: []ServerOption{WithProgressInterval(0)},
+			reports: []progressReport{
+				{progress: 1, total: 2, message: "first"},
+				{progress: 2},
+			},
+			errs: []error{nil, nil},
+			expected: []map[string]interface{}{
+				{
+					"progressToken": "abc",
+					"progress":      float64(1),
+					"total":         float64(2),
+					"message":       "first",
+				},
+				{"progressToken": "abc", "progress": float64(2)},
+			},
+		},
+		{
+			name: "Progress must increase",
+			message: `{
+                "jsonrpc": "2.0",
+                "id": 1,
+                "method": "tools/call",
+                "params": {"name": "progress", "_meta": {"progressToken": 7}}
+            }`,
+			opts: []ServerOption{WithProgressInterval(0)},
+			reports: []progressReport{
+				{progress: 5},
+				{progress: 5},
+				{progress: 3},
+				{progress: 6},
+			},
+			errs: []error{nil, ErrProgressNotIncreasing, ErrProgressNotIncreasing, nil},
+			expected: []map[string]interface{}{
+				{"progressToken": float64(7), "progress": float64(5)},
+				{"progressToken": float64(7), "progress": float64(6)},
+			},
+		},
+		{
+			name: "Bursts are rate limited",
+			message: `{
+                "jsonrpc": "2.0",
+                "id": 1,
+                "method": "tools/call",
+                "params": {"name": "progress", "_meta": {"progressToken": "abc"}}
+            }`,
+			opts: []ServerOption{WithProgressInterval(time.Hour)},
+			reports: []progressReport{
+				{progress: 1, total: 4},
+				{progress: 2, total: 4},
+				{progress: 3, total: 4},
+				{progress: 4, total: 4},
+			},
+			errs: []error{nil, nil, nil, nil},
+			expected: []map[string]interface{}{
+				{"progressToken": "abc", "progress": float64(1), "total": float64(4)},
+				{"progressToken": "abc", "progress": float64(4), "total": float64(4)},
+			},
+		},
+		{
+			name: "Latest held back report is sent before the response",
+			message: `{
+                "jsonrpc": "2.0",
+                "id": 1,
+                "method": "tools/call",
+                "params": {"name": "progress", "_meta": {"progressToken": "abc"}}
+            }`,
+			opts: []ServerOption{WithProgressInterval(time.Hour)},
+			reports: []progressReport{
+				{progress: 1, total: 4},
+				{progress: 2, total: 4},
+				{progress: 3, total: 4, message: "almost"},
+			},
+			errs: []error{nil, nil, nil},
+			expected: []map[string]interface{}{
+				{"progressToken": "abc", "progress": float64(1), "total": float64(4)},
+				{
+					"progressToken": "abc",
+					"progress":      float64(3),
+					"total":         float64(4),
+					"message":       "almost",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, errs := createProgressServer(tt.reports, tt.opts...)
+			ctx, session := newReadySession(t, server, "session-1")
+
+			response := server.HandleMessage(ctx, []byte(tt.message))
+			require.IsType(t, mcp.JSONRPCResponse{}, response)
+			assert.Equal(t, tt.errs, <-errs)
+
+			notifications := drainNotifications(session)
+			require.Len(t, notifications, len(tt.expected))
+			for i, notification := range notifications {
+				assert.Equal(t, "notifications/progress", notification.Method)
+				assert.Equal(t, tt.expected[i], notification.Params.AdditionalFields)
+			}
+		})
+	}
+}
+
+func TestProgressReporter_SendsHeldBackReportAfterInterval(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0",
+		WithProgressInterval(20*time.Millisecond),
+	)
+	release := make(chan struct{})
+	server.AddTool(
+		mcp.NewTool("progress"),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			progress := ProgressReporterFromContext(ctx)
+			_ = progress.Report(1, 10, "")
+			_ = progress.Report(2, 10, "")
+			<-release
+			return mcp.NewToolResultText("done"), nil
+		},
+	)
+	ctx, session := newReadySession(t, server, "session-1")
+
+	responses := make(chan mcp.JSONRPCMessage, 1)
+	go func() {
+		responses <- server.HandleMessage(ctx, []byte(`{
+            "jsonrpc": "2.0",
+            "id": 1,
+            "method": "tools/call",
+            "params": {"name": "progress", "_meta": {"progressToken": "abc"}}
+        }`))
+	}()
+
+	// The second report arrives while the tool is still running
+	var progress []interface{}
+	assert.Eventually(t, func() bool {
+		for _, notification := range drainNotifications(session) {
+			progress = append(progress, notification.Params.AdditionalFields["progress"])
+		}
+		return len(progress) == 2
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []interface{}{float64(1), float64(2)}, progress)
+
+	close(release)
+	require.IsType(t, mcp.JSONRPCResponse{}, <-responses)
+	assert.Empty(t, drainNotifications(session))
+}
+
+func TestProgressReporterFromContext_NoRequest(t *testing.T) {
+	progress := ProgressReporterFromContext(context.Background())
+	require.NotNil(t, progress)
+	assert.False(t, progress.Enabled())
+	assert.NoError(t, progress.Report(1, 1, ""))
+}
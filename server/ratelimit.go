@@ -0,0 +1,166 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/shaneholloman/mcp-server-go/mcp"
+)
+
+// RateLimiter decides whether a request may proceed. Allow reports whether
+// a request under key is allowed and, if it is not, how long to wait before
+// retrying.
+type RateLimiter interface {
+	Allow(key string) (bool, time.Duration)
+}
+
+// RateLimitKeyFunc returns the key a request is rate limited under. Requests
+// given an empty key are not limited.
+type RateLimitKeyFunc func(ctx context.Context, method string, message json.RawMessage) string
+
+// rateLimit is a limiter applied to the requests it keys
+type rateLimit struct {
+	limiter RateLimiter
+	key     RateLimitKeyFunc
+}
+
+// WithRateLimit limits the requests of clients with limiter, counting them
+// under the key returned by key. It can be given several times, and a
+// request must then be allowed by every limit. Rejected requests get a
+// RATE_LIMITED error with the number of seconds to wait in its retryAfter
+// data, and the SSE server answers their POST with 429 Too Many Requests,
+// as it does a batch whose every request was rejected.
+func WithRateLimit(limiter RateLimiter, key RateLimitKeyFunc) ServerOption {
+	return func(s *MCPServer) {
+		s.rateLimits = append(s.rateLimits, rateLimit{limiter: limiter, key: key})
+	}
+}
+
+// RateLimitBySession limits each client session separately
+func RateLimitBySession(ctx context.Context, method string, message json.RawMessage) string {
+	return ClientSessionFromContext(ctx).ID()
+}
+
+// RateLimitByClient limits each client by the name it gave in its
+// initialize request, so all the sessions of a client share a limit
+func RateLimitByClient(ctx context.Context, method string, message json.RawMessage) string {
+	session := ClientSessionFromContext(ctx)
+	if name := session.ClientInfo().Name; name != "" {
+		return name
+	}
+	return session.ID()
+}
+
+// RateLimitByTool limits the calls of each tool separately, across all
+// sessions. Other requests are not limited.
+func RateLimitByTool(ctx context.Context, method string, message json.RawMessage) string {
+	if method != "tools/call" {
+		return ""
+	}
+	var request mcp.CallToolRequest
+	if err := json.Unmarshal(message, &request); err != nil {
+		return ""
+	}
+	return request.Params.Name
+}
+
+// checkRateLimits returns a RATE_LIMITED error response if a rate limit
+// rejects the request, or nil if it may proceed
+func (s *MCPServer) checkRateLimits(
+	ctx context.Context,
+	id interface{},
+	method string,
+	message json.RawMessage,
+) mcp.JSONRPCMessage {
+	for _, limit := range s.rateLimits {
+		key := limit.key(ctx, method, message)
+		if key == "" {
+			continue
+		}
+		if ok, retryAfter := limit.limiter.Allow(key); !ok {
+			return mcp.NewJSONRPCErrorFromError(id, mcp.NewError(
+				mcp.RATE_LIMITED,
+				"Rate limit exceeded",
+				map[string]interface{}{"retryAfter": retryAfter.Seconds()},
+			))
+		}
+	}
+	return nil
+}
+
+// TokenBucketLimiter is an in-memory RateLimiter that gives every key a
+// bucket of tokens. Each request takes a token, and tokens are added back
+// at a steady rate up to the size of the bucket, which allows short bursts.
+type TokenBucketLimiter struct {
+	rate  float64
+	burst float64
+	now   func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// NewTokenBucketLimiter creates a limiter allowing rate requests per second
+// for each key, with bursts of up to burst requests
+func NewTokenBucketLimiter(rate float64, burst int) *TokenBucketLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &TokenBucketLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		now:     time.Now,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// Allow takes a token from the bucket of key if it has one, and otherwise
+// returns how long it takes for the next token to be added
+func (l *TokenBucketLimiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: l.burst, updated: now}
+		l.buckets[key] = bucket
+	}
+	bucket.tokens = math.Min(l.burst, bucket.tokens+now.Sub(bucket.updated).Seconds()*l.rate)
+	bucket.updated = now
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return true, 0
+	}
+	if l.rate <= 0 {
+		return false, time.Duration(math.MaxInt64)
+	}
+	wait := (1 - bucket.tokens) / l.rate
+	return false, time.Duration(wait * float64(time.Second))
+}
+
+// sweep forgets the buckets that have filled up again, which behave like
+// new ones, at most once per minute
+func (l *TokenBucketLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+	for key, bucket := range l.buckets {
+		if bucket.tokens+now.Sub(bucket.updated).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/shaneholloman/mcp-server-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenBucketLimiter(t *testing.T) {
+	now := time.Unix(0, 0)
+	limiter := NewTokenBucketLimiter(2, 2)
+	limiter.now = func() time.Time { return now }
+
+	allow := func(key string) (bool, time.Duration) {
+		return limiter.Allow(key)
+	}
+
+	// A full bucket allows a burst
+	ok, _ := allow("a")
+	assert.True(t, ok)
+	ok, _ = allow("a")
+	assert.True(t, ok)
+	ok, retryAfter := allow("a")
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, retryAfter)
+
+	// Keys have buckets of their own
+	ok, _ = allow("b")
+	assert.True(t, ok)
+
+	// Tokens are added back at the configured rate
+	now = now.Add(250 * time.Millisecond)
+	ok, retryAfter = allow("a")
+	assert.False(t, ok)
+	assert.Equal(t, 250*time.Millisecond, retryAfter)
+	now = now.Add(250 * time.Millisecond)
+	ok, _ = allow("a")
+	assert.True(t, ok)
+
+	// Buckets that have filled up are forgotten
+	now = now.Add(2 * time.Minute)
+	ok, _ = allow("c")
+	assert.True(t, ok)
+	assert.Len(t, limiter.buckets, 1)
+}
+
+// denyAll is a RateLimiter rejecting every request
+type denyAll struct{}
+
+func (denyAll) Allow(key string) (bool, time.Duration) {
+	return false, 1500 * time.Millisecond
+}
+
+func TestMCPServer_RateLimits(t *testing.T) {
+	tests := []struct {
+		name     string
+		key      RateLimitKeyFunc
+		messages []string
+		limited  []bool
+	}{
+		{
+			name: "By tool",
+			key:  RateLimitByTool,
+			messages: []string{
+				`{"jsonrpc": "2.0", "id": 1, "method": "tools/call", "params": {"name": "search"}}`,
+				`{"jsonrpc": "2.0", "id": 2, "method": "tools/call", "params": {"name": "search"}}`,
+				`{"jsonrpc": "2.0", "id": 3, "method": "tools/call", "params": {"name": "fetch"}}`,
+				`{"jsonrpc": "2.0", "id": 4, "method": "tools/list"}`,
+				`{"jsonrpc": "2.0", "id": 5, "method": "tools/list"}`,
+			},
+			limited: []bool{false, true, false, false, false},
+		},
+		{
+			name: "By session",
+			key:  RateLimitBySession,
+			messages: []string{
+				`{"jsonrpc": "2.0", "id": 1, "method": "ping"}`,
+				`{"jsonrpc": "2.0", "id": 2, "method": "tools/list"}`,
+			},
+			limited: []bool{false, true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := NewMCPServer("test-server", "1.0.0",
+				WithRateLimit(NewTokenBucketLimiter(0.001, 1), tt.key),
+			)
+			for _, name := range []string{"search", "fetch"} {
+				server.AddTool(mcp.NewTool(name), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+					return mcp.NewToolResultText("ok"), nil
+				})
+			}
+
+			for i, message := range tt.messages {
+				response := server.HandleMessage(context.Background(), []byte(message))
+				errorResponse, limited := response.(mcp.JSONRPCError)
+				require.Equal(t, tt.limited[i], limited, "message %d: %v", i, response)
+				if !limited {
+					continue
+				}
+				assert.Equal(t, mcp.RATE_LIMITED, errorResponse.Error.Code)
+				data, ok := errorResponse.Error.Data.(map[string]interface{})
+				require.True(t, ok)
+				assert.Greater(t, data["retryAfter"], 900.0)
+			}
+		})
+	}
+}
+
+func TestMCPServer_RateLimitsEverySession(t *testing.T) {
+	// Initialization is limited like any other request
+	server := NewMCPServer("test-server", "1.0.0",
+		WithRateLimit(NewTokenBucketLimiter(0.001, 3), RateLimitBySession),
+	)
+	first, _ := newReadySession(t, server, "session-1")
+	second, _ := newReadySession(t, server, "session-2")
+
+	ping := []byte(`{"jsonrpc": "2.0", "id": 1, "method": "ping"}`)
+	for _, ctx := range []context.Context{first, second} {
+		assert.IsType(t, mcp.JSONRPCResponse{}, server.HandleMessage(ctx, ping))
+		assert.IsType(t, mcp.JSONRPCResponse{}, server.HandleMessage(ctx, ping))
+		assert.IsType(t, mcp.JSONRPCError{}, server.HandleMessage(ctx, ping))
+	}
+}
+
+func TestRateLimitByClient(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0")
+	ctx, _ := newReadySession(t, server, "session-1")
+	assert.Equal(t, "test-client", RateLimitByClient(ctx, "ping", nil))
+
+	ctx, _ = newTestSession(t, server, "session-2")
+	assert.Equal(t, "session-2", RateLimitByClient(ctx, "ping", nil))
+}
+
+func TestSSEServer_RateLimited(t *testing.T) {
+	// Registered as a cleanup so that it runs after the stream is closed
+	testServer := NewTestServer(NewMCPServer("test-server", "1.0.0",
+		WithRateLimit(denyAll{}, RateLimitBySession),
+	))
+	t.Cleanup(testServer.Close)
+
+	client := connectTestSSE(t, testServer.URL)
+	resp, err := http.Post(
+		client.messageURL,
+		"application/json",
+		bytes.NewReader([]byte(initializeMessage)),
+	)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "2", resp.Header.Get("Retry-After"))
+
+	var response struct {
+		Error mcp.Error `json:"error"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+	assert.Equal(t, mcp.RATE_LIMITED, response.Error.Code)
+	assert.Equal(t, map[string]interface{}{"retryAfter": 1.5}, response.Error.Data)
+
+	// The rejection also reaches the event stream
+	assert.Contains(t, client.next(t), `"code":-32029`)
+}
+
+// denyKeys is a RateLimiter rejecting the keys it holds, each with its own
+// wait, and allowing any other
+type denyKeys map[string]time.Duration
+
+func (d denyKeys) Allow(key string) (bool, time.Duration) {
+	retryAfter, ok := d[key]
+	return !ok, retryAfter
+}
+
+func TestSSEServer_RateLimitedBatch(t *testing.T) {
+	testServer := NewTestServer(NewMCPServer("test-server", "1.0.0",
+		WithRateLimit(denyKeys{
+			"a": time.Second,
+			"b": 2500 * time.Millisecond,
+		}, RateLimitByTool),
+	))
+	t.Cleanup(testServer.Close)
+
+	client := connectTestSSE(t, testServer.URL)
+	client.initialize(t)
+
+	tests := []struct {
+		name       string
+		batch      string
+		status     int
+		retryAfter string
+	}{
+		{
+			name: "Every request limited",
+			batch: `[
+				{"jsonrpc": "2.0", "id": 1, "method": "tools/call", "params": {"name": "a"}},
+				{"jsonrpc": "2.0", "id": 2, "method": "tools/call", "params": {"name": "b"}}
+			]`,
+			status:     http.StatusTooManyRequests,
+			retryAfter: "3",
+		},
+		{
+			name: "Some requests allowed",
+			batch: `[
+				{"jsonrpc": "2.0", "id": 3, "method": "tools/call", "params": {"name": "a"}},
+				{"jsonrpc": "2.0", "id": 4, "method": "ping"}
+			]`,
+			status: http.StatusAccepted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Post(
+				client.messageURL,
+				"application/json",
+				bytes.NewReader([]byte(tt.batch)),
+			)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.status, resp.StatusCode)
+			assert.Equal(t, tt.retryAfter, resp.Header.Get("Retry-After"))
+			var responses []json.RawMessage
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&responses))
+			assert.Len(t, responses, 2)
+			assert.Contains(t, client.next(t), `"code":-32029`)
+		})
+	}
+}
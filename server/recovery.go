@@ -0,0 +1,55 @@
+package server
+
+import (
+	"context"
+	"log"
+)
+
+// WithErrorLogger sets where the server logs handler panics that no OnPanic
+// hook was installed for. By default they are logged to stderr.
+func WithErrorLogger(logger *log.Logger) ServerOption {
+	return func(s *MCPServer) {
+		s.errLogger = logger
+	}
+}
+
+// reportPanic passes a recovered panic to the OnPanic hooks, or logs it with
+// its stack if there are none
+func (s *MCPServer) reportPanic(
+	ctx context.Context,
+	method string,
+	id interface{},
+	value interface{},
+	stack []byte,
+) {
+	if s.onPanic(ctx, method, id, value, stack) {
+		return
+	}
+	if id != nil {
+		s.errLogger.Printf("panic handling %s request %v: %v\n%s", method, id, value, stack)
+	} else {
+		s.errLogger.Printf("panic handling %s notification: %v\n%s", method, value, stack)
+	}
+}
+
+// handlerPanic carries a panic raised in a goroutine running a handler over
+// to the goroutine handling the request, along with the stack of the
+// goroutine that panicked
+type handlerPanic struct {
+	value interface{}
+	stack []byte
+}
+
+// requestIDKey is the context key of the ID of the request being handled
+type requestIDKey struct{}
+
+// withRequestID returns a copy of ctx carrying the ID of the request being
+// handled, so that panics in goroutines started for it can be reported
+func withRequestID(ctx context.Context, id interface{}) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// requestIDFromContext returns the ID of the request being handled with ctx
+func requestIDFromContext(ctx context.Context) interface{} {
+	return ctx.Value(requestIDKey{})
+}
@@ -0,0 +1,204 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"testing"
+
+	"github.com/shaneholloman/mcp-server-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// panicRecord is a panic reported through the OnPanic hook
+type panicRecord struct {
+	method string
+	id     interface{}
+	value  interface{}
+	stack  string
+}
+
+func createPanickingServer(opts ...ServerOption) *MCPServer {
+	server := NewMCPServer("test-server", "1.0.0", append([]ServerOption{
+		WithResourceCapabilities(false, false),
+		WithPromptCapabilities(false),
+	}, opts...)...)
+	server.AddTool(
+		mcp.NewTool("panic"),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			panic("tool exploded")
+		},
+	)
+	server.AddTool(
+		mcp.NewTool("ok"),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return mcp.NewToolResultText("ok"), nil
+		},
+	)
+	server.AddPrompt(
+		mcp.Prompt{Name: "panic"},
+		func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+			panic("prompt exploded")
+		},
+	)
+	server.AddResource(
+		mcp.NewResource("test://panic", "Panic"),
+		func(ctx context.Context, request mcp.ReadResourceRequest) ([]interface{}, error) {
+			panic("resource exploded")
+		},
+	)
+	server.AddNotificationHandler(
+		"test/panic",
+		func(ctx context.Context, notification mcp.JSONRPCNotification) {
+			panic("notification exploded")
+		},
+	)
+	return server
+}
+
+func TestMCPServer_PanicRecovery(t *testing.T) {
+	var panics []panicRecord
+	server := createPanickingServer(WithHooks(&Hooks{
+		OnPanic: func(
+			ctx context.Context,
+			method string,
+			id interface{},
+			value interface{},
+			stack []byte,
+		) {
+			panics = append(panics, panicRecord{method, id, value, string(stack)})
+		},
+	}))
+	ctx, session := newReadySession(t, server, "session-1")
+
+	tests := []struct {
+		name    string
+		message string
+		method  string
+		value   string
+	}{
+		{
+			name:    "Tool",
+			message: string(callToolMessage(1, "panic")),
+			method:  "tools/call",
+			value:   "tool exploded",
+		},
+		{
+			name: "Prompt",
+			message: `{
+                "jsonrpc": "2.0",
+                "id": 1,
+                "method": "prompts/get",
+                "params": {"name": "panic"}
+            }`,
+			method: "prompts/get",
+			value:  "prompt exploded",
+		},
+		{
+			name: "Resource",
+			message: `{
+                "jsonrpc": "2.0",
+                "id": 1,
+                "method": "resources/read",
+                "params": {"uri": "test://panic"}
+            }`,
+			method: "resources/read",
+			value:  "resource exploded",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			panics = nil
+			response := server.HandleMessage(ctx, []byte(tt.message))
+
+			errorResponse, ok := response.(mcp.JSONRPCError)
+			require.True(t, ok)
+			assert.Equal(t, float64(1), errorResponse.ID)
+			assert.Equal(t, mcp.INTERNAL_ERROR, errorResponse.Error.Code)
+
+			require.Len(t, panics, 1)
+			assert.Equal(t, tt.method, panics[0].method)
+			assert.Equal(t, float64(1), panics[0].id)
+			assert.Equal(t, tt.value, panics[0].value)
+			assert.Contains(t, panics[0].stack, "createPanickingServer")
+
+			// The request is no longer tracked and the server keeps serving
+			assert.Empty(t, session.inFlight)
+			response = server.HandleMessage(ctx, callToolMessage(2, "ok"))
+			assert.IsType(t, mcp.JSONRPCResponse{}, response)
+		})
+	}
+
+	t.Run("Notification", func(t *testing.T) {
+		panics = nil
+		response := server.HandleMessage(ctx, []byte(`{
+            "jsonrpc": "2.0",
+            "method": "test/panic"
+        }`))
+		assert.Nil(t, response)
+
+		require.Len(t, panics, 1)
+		assert.Equal(t, "test/panic", panics[0].method)
+		assert.Nil(t, panics[0].id)
+		assert.Equal(t, "notification exploded", panics[0].value)
+	})
+}
+
+func TestMCPServer_PanicLogged(t *testing.T) {
+	var logs bytes.Buffer
+	server := createPanickingServer(WithErrorLogger(log.New(&logs, "", 0)))
+
+	response := server.HandleMessage(context.Background(), callToolMessage(1, "panic"))
+	assert.IsType(t, mcp.JSONRPCError{}, response)
+	assert.Contains(t, logs.String(), "panic handling tools/call request 1: tool exploded")
+	assert.Contains(t, logs.String(), "createPanickingServer")
+}
+
+func TestStdioServer_PanicRecovery(t *testing.T) {
+	stdinReader, stdinWriter := io.Pipe()
+	stdoutReader, stdoutWriter := io.Pipe()
+
+	server := createPanickingServer(WithErrorLogger(log.New(io.Discard, "", 0)))
+	stdioServer := NewStdioServer(server)
+	stdioServer.SetErrorLogger(log.New(io.Discard, "", 0))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go stdioServer.Listen(ctx, stdinReader, stdoutWriter)
+	defer func() {
+		stdinWriter.Close()
+		stdoutWriter.Close()
+	}()
+
+	scanner := bufio.NewScanner(stdoutReader)
+	send := func(message []byte) {
+		var line bytes.Buffer
+		require.NoError(t, json.Compact(&line, message))
+		_, err := stdinWriter.Write(append(line.Bytes(), '\n'))
+		require.NoError(t, err)
+	}
+	receive := func() map[string]interface{} {
+		require.True(t, scanner.Scan())
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &response))
+		return response
+	}
+
+	send([]byte(initializeMessage))
+	receive()
+
+	send(callToolMessage(1, "panic"))
+	response := receive()
+	assert.Equal(t, float64(1), response["id"])
+	assert.NotNil(t, response["error"])
+
+	send(callToolMessage(2, "ok"))
+	response = receive()
+	assert.Equal(t, float64(2), response["id"])
+	assert.NotNil(t, response["result"])
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shaneholloman/mcp-server-go/mcp"
+)
+
+// ErrRootsNotSupported is returned by ListRoots when the client did not
+// declare the roots capability during initialization.
+var ErrRootsNotSupported = errors.New("client does not support roots")
+
+// rootsRefreshTimeout bounds the roots/list request sent after the client
+// reports that its roots changed
+const rootsRefreshTimeout = 30 * time.Second
+
+// ListRoots returns the roots the client whose request is being handled with
+// ctx exposes to the server, such as the directories a filesystem server may
+// operate on. Roots are fetched from the client on first use and cached for
+// the session; the cache is refreshed whenever the client sends
+// notifications/roots/list_changed.
+func (s *MCPServer) ListRoots(ctx context.Context) ([]mcp.Root, error) {
+	session := s.sessionFromContext(ctx)
+	if roots, ok := session.Roots(); ok {
+		return roots, nil
+	}
+	return s.fetchRoots(ctx, session)
+}
+
+// fetchRoots requests the roots from the client and caches them
+func (s *MCPServer) fetchRoots(
+	ctx context.Context,
+	session *ClientSession,
+) ([]mcp.Root, error) {
+	if session.ClientCapabilities().Roots == nil {
+		return nil, ErrRootsNotSupported
+	}
+
+	generation := session.rootsVersion()
+	response, err := session.request(ctx, "roots/list", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result mcp.ListRootsResult
+	if err := json.Unmarshal(response, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal roots: %w", err)
+	}
+	if result.Roots == nil {
+		result.Roots = []mcp.Root{}
+	}
+
+	session.setRoots(result.Roots, generation)
+	return result.Roots, nil
+}
+
+// handleRootsListChanged discards the cached roots of the session and fetches
+// the new ones in the background
+func (s *MCPServer) handleRootsListChanged(ctx context.Context) {
+	session := ClientSessionFromContext(ctx)
+	session.invalidateRoots()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(
+			context.Background(),
+			rootsRefreshTimeout,
+		)
+		defer cancel()
+		// Failures leave the cache empty, so the next ListRoots call retries
+		_, _ = s.fetchRoots(ctx, session)
+	}()
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/shaneholloman/mcp-server-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRootsSession returns a session whose client declared roots support
+func newRootsSession(t *testing.T, server *MCPServer) (context.Context, *ClientSession) {
+	ctx, session := newTestSession(t, server, "session-1")
+	var initialize mcp.InitializeRequest
+	initialize.Params.Capabilities.Roots = &struct {
+		ListChanged bool `json:"listChanged,omitempty"`
+	}{ListChanged: true}
+	session.initialize(initialize, mcp.LATEST_PROTOCOL_VERSION)
+	return ctx, session
+}
+
+// answerRoots waits for a roots/list request and answers it with uris
+func answerRoots(
+	t *testing.T,
+	server *MCPServer,
+	ctx context.Context,
+	session *ClientSession,
+	uris ...string,
+) {
+	t.Helper()
+	request := nextServerRequest(t, session)
+	require.Equal(t, "roots/list", request.Method)
+
+	roots := make([]mcp.Root, len(uris))
+	for i, uri := range uris {
+		roots[i] = mcp.Root{URI: uri}
+	}
+	server.HandleMessage(ctx, []byte(fmt.Sprintf(
+		`{"jsonrpc": "2.0", "id": %d, "result": %s}`,
+		request.ID,
+		mustMarshal(t, mcp.ListRootsResult{Roots: roots}),
+	)))
+}
+
+func mustMarshal(t *testing.T, v interface{}) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestMCPServer_ListRoots(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0")
+	ctx, session := newRootsSession(t, server)
+
+	_, ok := session.Roots()
+	assert.False(t, ok)
+
+	rootsChan := make(chan []mcp.Root, 1)
+	go func() {
+		roots, err := server.ListRoots(ctx)
+		assert.NoError(t, err)
+		rootsChan <- roots
+	}()
+	answerRoots(t, server, ctx, session, "file:///workspace")
+	assert.Equal(t, []mcp.Root{{URI: "file:///workspace"}}, <-rootsChan)
+
+	// Cached roots are served without asking the client again
+	roots, err := server.ListRoots(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []mcp.Root{{URI: "file:///workspace"}}, roots)
+	assert.Empty(t, session.Messages())
+
+	// A change notification invalidates the cache and triggers a refresh
+	response := server.HandleMessage(ctx, []byte(`{
+        "jsonrpc": "2.0",
+        "method": "notifications/roots/list_changed"
+    }`))
+	assert.Nil(t, response)
+	_, ok = session.Roots()
+	assert.False(t, ok)
+
+	answerRoots(t, server, ctx, session, "file:///a", "file:///b")
+	assert.Eventually(t, func() bool {
+		_, ok := session.Roots()
+		return ok
+	}, time.Second, 10*time.Millisecond)
+
+	roots, err = server.ListRoots(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []mcp.Root{{URI: "file:///a"}, {URI: "file:///b"}}, roots)
+}
+
+func TestMCPServer_ListRootsNotSupported(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0")
+	ctx, session := newTestSession(t, server, "session-1")
+
+	_, err := server.ListRoots(ctx)
+	assert.ErrorIs(t, err, ErrRootsNotSupported)
+	assert.Empty(t, session.Messages())
+}
+
+func TestClientSession_StaleRootsDiscarded(t *testing.T) {
+	session := NewClientSession("session-1")
+
+	generation := session.rootsVersion()
+	session.invalidateRoots()
+	session.setRoots([]mcp.Root{{URI: "file:///stale"}}, generation)
+
+	_, ok := session.Roots()
+	assert.False(t, ok)
+}
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/shaneholloman/mcp-server-go/mcp"
+)
+
+// ErrSamplingNotSupported is returned by RequestSampling when the client did
+// not declare the sampling capability during initialization.
+var ErrSamplingNotSupported = errors.New("client does not support sampling")
+
+// RequestSampling asks the client whose request is being handled with ctx to
+// sample a message from an LLM, and waits for the result. The request is
+// abandoned, and the client told so, when ctx is cancelled or times out.
+//
+// It is meant to be called from within a handler, e.g. a tool that needs an
+// LLM completion to produce its result.
+func (s *MCPServer) RequestSampling(
+	ctx context.Context,
+	request mcp.CreateMessageRequest,
+) (*mcp.CreateMessageResult, error) {
+	session := s.sessionFromContext(ctx)
+	if session.ClientCapabilities().Sampling == nil {
+		return nil, ErrSamplingNotSupported
+	}
+
+	response, err := session.request(ctx, "sampling/createMessage", request.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	var result mcp.CreateMessageResult
+	if err := json.Unmarshal(response, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal sampling result: %w", err)
+	}
+	return &result, nil
+}
@@ -0,0 +1,230 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/shaneholloman/mcp-server-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createSamplingRequest(text string) mcp.CreateMessageRequest {
+	var request mcp.CreateMessageRequest
+	request.Params.Messages = []mcp.SamplingMessage{
+		{
+			Role:    mcp.RoleUser,
+			Content: mcp.NewTextContent(text),
+		},
+	}
+	request.Params.MaxTokens = 10
+	return request
+}
+
+// newSamplingSession returns a session whose client declared sampling support
+func newSamplingSession(t *testing.T, server *MCPServer) (context.Context, *ClientSession) {
+	ctx, session := newTestSession(t, server, "session-1")
+	var initialize mcp.InitializeRequest
+	initialize.Params.Capabilities.Sampling = &struct{}{}
+	session.initialize(initialize, mcp.LATEST_PROTOCOL_VERSION)
+	return ctx, session
+}
+
+// nextServerRequest waits for the next request the server sends to the session
+func nextServerRequest(t *testing.T, session *ClientSession) serverRequest {
+	t.Helper()
+	select {
+	case message := <-session.Messages():
+		request, ok := message.(serverRequest)
+		require.True(t, ok, "unexpected message: %v", message)
+		return request
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for server request")
+		return serverRequest{}
+	}
+}
+
+func TestMCPServer_RequestSampling(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0")
+	ctx, session := newSamplingSession(t, server)
+
+	type samplingResult struct {
+		result *mcp.CreateMessageResult
+		err    error
+	}
+	results := make(chan samplingResult, 1)
+	go func() {
+		result, err := server.RequestSampling(ctx, createSamplingRequest("hello"))
+		results <- samplingResult{result, err}
+	}()
+
+	request := nextServerRequest(t, session)
+	assert.Equal(t, "sampling/createMessage", request.Method)
+	params, err := json.Marshal(request.Params)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+        "messages": [{"role": "user", "content": {"type": "text", "text": "hello"}}],
+        "maxTokens": 10
+    }`, string(params))
+
+	// A response to an unknown request is ignored
+	response := server.HandleMessage(ctx, []byte(
+		`{"jsonrpc": "2.0", "id": 999, "result": {}}`,
+	))
+	assert.Nil(t, response)
+
+	response = server.HandleMessage(ctx, []byte(fmt.Sprintf(`{
+        "jsonrpc": "2.0",
+        "id": %d,
+        "result": {
+            "role": "assistant",
+            "content": {"type": "text", "text": "hi there"},
+            "model": "test-model",
+            "stopReason": "endTurn"
+        }
+    }`, request.ID)))
+	assert.Nil(t, response)
+
+	select {
+	case r := <-results:
+		require.NoError(t, r.err)
+		assert.Equal(t, mcp.RoleAssistant, r.result.Role)
+		assert.Equal(t, "test-model", r.result.Model)
+		assert.Equal(t, "endTurn", r.result.StopReason)
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for sampling result")
+	}
+}
+
+func TestMCPServer_RequestSamplingErrors(t *testing.T) {
+	t.Run("Client without sampling capability", func(t *testing.T) {
+		server := NewMCPServer("test-server", "1.0.0")
+		ctx, session := newTestSession(t, server, "session-1")
+
+		_, err := server.RequestSampling(ctx, createSamplingRequest("hello"))
+		assert.ErrorIs(t, err, ErrSamplingNotSupported)
+		assert.Empty(t, session.Messages())
+	})
+
+	t.Run("Client returns an error", func(t *testing.T) {
+		server := NewMCPServer("test-server", "1.0.0")
+		ctx, session := newSamplingSession(t, server)
+
+		errs := make(chan error, 1)
+		go func() {
+			_, err := server.RequestSampling(ctx, createSamplingRequest("hello"))
+			errs <- err
+		}()
+
+		request := nextServerRequest(t, session)
+		server.HandleMessage(ctx, []byte(fmt.Sprintf(`{
+            "jsonrpc": "2.0",
+            "id": %d,
+            "error": {"code": -1, "message": "User rejected sampling request"}
+        }`, request.ID)))
+
+		err := <-errs
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "User rejected sampling request")
+	})
+
+	t.Run("Context cancelled", func(t *testing.T) {
+		server := NewMCPServer("test-server", "1.0.0")
+		ctx, session := newSamplingSession(t, server)
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+
+		_, err := server.RequestSampling(ctx, createSamplingRequest("hello"))
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		request := nextServerRequest(t, session)
+		notifications := drainNotifications(session)
+		require.Len(t, notifications, 1)
+		assert.Equal(t, "notifications/cancelled", notifications[0].Method)
+		assert.Equal(
+			t,
+			request.ID,
+			notifications[0].Params.AdditionalFields["requestId"],
+		)
+	})
+
+	t.Run("Session closed", func(t *testing.T) {
+		server := NewMCPServer("test-server", "1.0.0")
+		ctx, session := newSamplingSession(t, server)
+
+		errs := make(chan error, 1)
+		go func() {
+			_, err := server.RequestSampling(ctx, createSamplingRequest("hello"))
+			errs <- err
+		}()
+
+		nextServerRequest(t, session)
+		server.UnregisterSession(session.ID())
+		assert.Error(t, <-errs)
+	})
+}
+
+func TestSSEServer_RequestSampling(t *testing.T) {
+	mcpServer := NewMCPServer("test-server", "1.0.0")
+	mcpServer.AddTool(
+		mcp.NewTool("sample"),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			result, err := ServerFromContext(ctx).RequestSampling(
+				ctx,
+				createSamplingRequest("hello"),
+			)
+			if err != nil {
+				return nil, err
+			}
+			return mcp.NewToolResultText(result.Model), nil
+		},
+	)
+
+	testServer := NewTestServer(mcpServer)
+	t.Cleanup(testServer.Close)
+	client := connectTestSSE(t, testServer.URL)
+
+	client.post(t, []byte(`{
+        "jsonrpc": "2.0",
+        "id": 1,
+        "method": "initialize",
+        "params": {
+            "protocolVersion": "2024-11-05",
+            "capabilities": {"sampling": {}},
+            "clientInfo": {"name": "test-client", "version": "1.0.0"}
+        }
+    }`))
+	client.next(t) // initialize response
+
+	// The tool call blocks until the sampling request is answered
+	go client.post(t, callToolMessage(2, "sample"))
+
+	var request struct {
+		ID     int64  `json:"id"`
+		Method string `json:"method"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(client.next(t)), &request))
+	assert.Equal(t, "sampling/createMessage", request.Method)
+
+	client.post(t, []byte(fmt.Sprintf(`{
+        "jsonrpc": "2.0",
+        "id": %d,
+        "result": {
+            "role": "assistant",
+            "content": {"type": "text", "text": "hi"},
+            "model": "test-model"
+        }
+    }`, request.ID)))
+
+	var response struct {
+		ID     int64              `json:"id"`
+		Result mcp.CallToolResult `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(client.next(t)), &response))
+	assert.Equal(t, int64(2), response.ID)
+	require.Len(t, response.Result.Content, 1)
+	assert.Equal(t, "test-model", response.Result.Content[0].(map[string]interface{})["text"])
+}
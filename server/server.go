@@ -5,7 +5,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"regexp"
+	"log"
+	"os"
+	"runtime/debug"
+	"sync"
+	"time"
 
 	"github.com/shaneholloman/mcp-server-go/mcp"
 )
@@ -14,12 +18,59 @@ import (
 type resourceEntry struct {
 	resource mcp.Resource
 	handler  ResourceHandlerFunc
+	cache    *resultCache
 }
 
-// resourceTemplateEntry holds both a template and its handler
+// resourceTemplateEntry holds both a template and its handler, along with
+// the parsed URI template, which is nil if the template is invalid
 type resourceTemplateEntry struct {
-	template mcp.ResourceTemplate
-	handler  ResourceTemplateHandlerFunc
+	template    mcp.ResourceTemplate
+	uriTemplate *mcp.URITemplate
+	handler     ResourceTemplateHandlerFunc
+	cache       *resultCache
+}
+
+// promptEntry holds both a prompt and its handler
+type promptEntry struct {
+	prompt  mcp.Prompt
+	handler PromptHandlerFunc
+}
+
+// toolEntry holds both a tool and its handler
+type toolEntry struct {
+	tool    mcp.Tool
+	handler ToolHandlerFunc
+	limiter *toolLimiter
+	cache   *resultCache
+}
+
+// ServerResource pairs a resource with its handler for SetResources
+type ServerResource struct {
+	Resource mcp.Resource
+	Handler  ResourceHandlerFunc
+	Options  []ResourceRegistrationOption
+}
+
+// ServerResourceTemplate pairs a resource template with its handler for
+// SetResourceTemplates
+type ServerResourceTemplate struct {
+	Template mcp.ResourceTemplate
+	Handler  ResourceTemplateHandlerFunc
+	Options  []ResourceRegistrationOption
+}
+
+// ServerPrompt pairs a prompt with its handler for SetPrompts
+type ServerPrompt struct {
+	Prompt  mcp.Prompt
+	Handler PromptHandlerFunc
+	Options []PromptRegistrationOption
+}
+
+// ServerTool pairs a tool with its handler for SetTools
+type ServerTool struct {
+	Tool    mcp.Tool
+	Handler ToolHandlerFunc
+	Options []ToolRegistrationOption
 }
 
 // ServerOption is a function that configures an MCPServer.
@@ -29,6 +80,8 @@ type ServerOption func(*MCPServer)
 type ResourceHandlerFunc func(ctx context.Context, request mcp.ReadResourceRequest) ([]interface{}, error)
 
 // ResourceTemplateHandlerFunc is a function that returns a resource template.
+// The values of the template's variables in the requested URI are passed in
+// request.Params.Arguments.
 type ResourceTemplateHandlerFunc func(ctx context.Context, request mcp.ReadResourceRequest) ([]interface{}, error)
 
 // PromptHandlerFunc handles prompt requests with given arguments.
@@ -37,37 +90,43 @@ type PromptHandlerFunc func(ctx context.Context, request mcp.GetPromptRequest) (
 // ToolHandlerFunc handles tool calls with given arguments.
 type ToolHandlerFunc func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error)
 
-// NotificationContext provides client identification for notifications
-type NotificationContext struct {
-	ClientID  string
-	SessionID string
-}
-
-// ServerNotification combines the notification with client context
-type ServerNotification struct {
-	Context      NotificationContext
-	Notification mcp.JSONRPCNotification
-}
-
 // NotificationHandlerFunc handles incoming notifications.
 type NotificationHandlerFunc func(ctx context.Context, notification mcp.JSONRPCNotification)
 
 // MCPServer implements a Model Control Protocol server that can handle various types of requests
 // including resources, prompts, and tools.
+//
+// An MCPServer is safe for concurrent use: resources, prompts and tools may be
+// registered or removed while requests are being dispatched from any number of
+// goroutines. Handlers are always invoked without holding the registry lock.
 type MCPServer struct {
-	name                 string
-	version              string
-	resources            map[string]resourceEntry
-	resourceTemplates    map[string]resourceTemplateEntry
-	prompts              map[string]mcp.Prompt
-	promptHandlers       map[string]PromptHandlerFunc
-	tools                map[string]mcp.Tool
-	toolHandlers         map[string]ToolHandlerFunc
-	notificationHandlers map[string]NotificationHandlerFunc
-	capabilities         serverCapabilities
-	notifications        chan ServerNotification
-	currentClient        NotificationContext
-	initialized          bool
+	name                  string
+	version               string
+	instructions          string
+	mu                    sync.RWMutex // guards the registries below
+	resources             map[string]resourceEntry
+	resourceTemplates     map[string]resourceTemplateEntry
+	prompts               map[string]promptEntry
+	tools                 map[string]toolEntry
+	toolsRegistered       bool // set once the first tool is registered
+	notificationHandlers  map[string]NotificationHandlerFunc
+	completions           map[completionKey]CompletionHandlerFunc
+	capabilities          serverCapabilities
+	sessionsMu            sync.RWMutex
+	sessions              map[string]*ClientSession
+	defaultSession        *ClientSession
+	pageSize              int
+	progressInterval      time.Duration
+	toolMiddleware        []ToolMiddleware
+	promptMiddleware      []PromptMiddleware
+	resourceMiddleware    []ResourceMiddleware
+	validateToolArguments bool
+	hooks                 []*Hooks
+	errLogger             *log.Logger
+	protocolVersions      []string
+	batchConcurrency      int
+	toolLimits            toolLimits
+	rateLimits            []rateLimit
 }
 
 // serverKey is the context key for storing the server instance
@@ -81,50 +140,13 @@ func ServerFromContext(ctx context.Context) *MCPServer {
 	return nil
 }
 
-// WithContext sets the current client context and returns the provided context
-func (s *MCPServer) WithContext(
-	ctx context.Context,
-	notifCtx NotificationContext,
-) context.Context {
-	s.currentClient = notifCtx
-	return ctx
-}
-
-// SendNotificationToClient sends a notification to the current client
-func (s *MCPServer) SendNotificationToClient(
-	method string,
-	params map[string]interface{},
-) error {
-	if s.notifications == nil {
-		return fmt.Errorf("notification channel not initialized")
-	}
-
-	notification := mcp.JSONRPCNotification{
-		JSONRPC: mcp.JSONRPC_VERSION,
-		Notification: mcp.Notification{
-			Method: method,
-			Params: mcp.NotificationParams{
-				AdditionalFields: params,
-			},
-		},
-	}
-
-	select {
-	case s.notifications <- ServerNotification{
-		Context:      s.currentClient,
-		Notification: notification,
-	}:
-		return nil
-	default:
-		return fmt.Errorf("notification channel full or blocked")
-	}
-}
-
 // serverCapabilities defines the supported features of the MCP server
 type serverCapabilities struct {
-	resources *resourceCapabilities
-	prompts   *promptCapabilities
-	logging   bool
+	resources    *resourceCapabilities
+	prompts      *promptCapabilities
+	tools        *toolCapabilities
+	logging      bool
+	experimental map[string]interface{}
 }
 
 // resourceCapabilities defines the supported resource-related features
@@ -138,6 +160,11 @@ type promptCapabilities struct {
 	listChanged bool
 }
 
+// toolCapabilities defines the supported tool-related features
+type toolCapabilities struct {
+	listChanged bool
+}
+
 // WithResourceCapabilities configures resource-related server capabilities
 func WithResourceCapabilities(subscribe, listChanged bool) ServerOption {
 	return func(s *MCPServer) {
@@ -157,6 +184,36 @@ func WithPromptCapabilities(listChanged bool) ServerOption {
 	}
 }
 
+// WithToolCapabilities configures tool-related server capabilities. Without
+// it, tools are supported once the first one is registered and list changes
+// are announced.
+func WithToolCapabilities(listChanged bool) ServerOption {
+	return func(s *MCPServer) {
+		s.capabilities.tools = &toolCapabilities{
+			listChanged: listChanged,
+		}
+	}
+}
+
+// WithExperimental declares a non-standard capability, advertised to clients
+// under the given name with the given configuration
+func WithExperimental(name string, config interface{}) ServerOption {
+	return func(s *MCPServer) {
+		if s.capabilities.experimental == nil {
+			s.capabilities.experimental = make(map[string]interface{})
+		}
+		s.capabilities.experimental[name] = config
+	}
+}
+
+// WithInstructions sets the instructions sent to clients during
+// initialization, describing how to use the server and its features
+func WithInstructions(instructions string) ServerOption {
+	return func(s *MCPServer) {
+		s.instructions = instructions
+	}
+}
+
 // WithLogging enables logging capabilities for the server
 func WithLogging() ServerOption {
 	return func(s *MCPServer) {
@@ -164,6 +221,18 @@ func WithLogging() ServerOption {
 	}
 }
 
+// WithProtocolVersions sets the protocol versions the server supports, most
+// preferred first. A client asking for one of them gets it; any other client
+// is offered the first. By default only mcp.LATEST_PROTOCOL_VERSION is
+// supported.
+func WithProtocolVersions(versions ...string) ServerOption {
+	return func(s *MCPServer) {
+		if len(versions) > 0 {
+			s.protocolVersions = versions
+		}
+	}
+}
+
 // NewMCPServer creates a new MCP server instance with the given name, version and options
 func NewMCPServer(
 	name, version string,
@@ -172,15 +241,21 @@ func NewMCPServer(
 	s := &MCPServer{
 		resources:            make(map[string]resourceEntry),
 		resourceTemplates:    make(map[string]resourceTemplateEntry),
-		prompts:              make(map[string]mcp.Prompt),
-		promptHandlers:       make(map[string]PromptHandlerFunc),
-		tools:                make(map[string]mcp.Tool),
-		toolHandlers:         make(map[string]ToolHandlerFunc),
+		prompts:              make(map[string]promptEntry),
+		tools:                make(map[string]toolEntry),
 		name:                 name,
 		version:              version,
 		notificationHandlers: make(map[string]NotificationHandlerFunc),
-		notifications:        make(chan ServerNotification, 100),
+		completions:          make(map[completionKey]CompletionHandlerFunc),
+		sessions:             make(map[string]*ClientSession),
+		defaultSession:       NewClientSession(defaultSessionID),
+		progressInterval:     defaultProgressInterval,
+		protocolVersions:     []string{mcp.LATEST_PROTOCOL_VERSION},
+		errLogger:            log.New(os.Stderr, "", log.LstdFlags),
+		batchConcurrency:     1,
 	}
+	s.defaultSession.shared = true
+	s.sessions[defaultSessionID] = s.defaultSession
 
 	for _, opt := range opts {
 		opt(s)
@@ -189,18 +264,35 @@ func NewMCPServer(
 	return s
 }
 
-// HandleMessage processes an incoming JSON-RPC message and returns an appropriate response
+// HandleMessage processes an incoming JSON-RPC message and returns an appropriate response.
+// The message may also be a JSON-RPC batch, in which case the responses are
+// returned as a []mcp.JSONRPCMessage, or nil if the batch held only
+// notifications and responses.
 func (s *MCPServer) HandleMessage(
 	ctx context.Context,
 	message json.RawMessage,
 ) mcp.JSONRPCMessage {
 	// Add server to context
 	ctx = context.WithValue(ctx, serverKey{}, s)
+	ctx = s.WithContext(ctx, s.sessionFromContext(ctx))
+
+	if isBatch(message) {
+		return s.handleBatch(ctx, message)
+	}
+	return s.handleMessage(ctx, message)
+}
 
+// handleMessage processes a single JSON-RPC message
+func (s *MCPServer) handleMessage(
+	ctx context.Context,
+	message json.RawMessage,
+) mcp.JSONRPCMessage {
 	var baseMessage struct {
-		JSONRPC string      `json:"jsonrpc"`
-		Method  string      `json:"method"`
-		ID      interface{} `json:"id,omitempty"`
+		JSONRPC string          `json:"jsonrpc"`
+		Method  string          `json:"method"`
+		ID      interface{}     `json:"id,omitempty"`
+		Result  json.RawMessage `json:"result,omitempty"`
+		Error   *mcp.Error      `json:"error,omitempty"`
 	}
 
 	if err := json.Unmarshal(message, &baseMessage); err != nil {
@@ -233,31 +325,127 @@ func (s *MCPServer) HandleMessage(
 		return nil // Return nil for notifications
 	}
 
-	switch baseMessage.Method {
+	// Responses to requests the server sent to the client
+	if baseMessage.Method == "" &&
+		(baseMessage.Result != nil || baseMessage.Error != nil) {
+		response := &clientResponse{result: baseMessage.Result}
+		if baseMessage.Error != nil {
+			response.err = baseMessage.Error
+		}
+		ClientSessionFromContext(ctx).deliver(baseMessage.ID, response)
+		return nil
+	}
+
+	s.beforeRequest(ctx, baseMessage.Method, baseMessage.ID)
+	start := time.Now()
+	response, cancelled := s.serveRequest(ctx, baseMessage.ID, baseMessage.Method, message)
+	s.afterRequest(ctx, baseMessage.Method, baseMessage.ID, response, cancelled, time.Since(start))
+	if cancelled {
+		return nil
+	}
+	return response
+}
+
+// serveRequest enforces the session lifecycle and cancellation around
+// handleRequest. It reports whether the client cancelled the request, in
+// which case its response must not be sent.
+func (s *MCPServer) serveRequest(
+	ctx context.Context,
+	id interface{},
+	method string,
+	message json.RawMessage,
+) (mcp.JSONRPCMessage, bool) {
+	// Until initialization the client may only send pings. Callers using
+	// HandleMessage without a session share the default session, which is
+	// exempt.
+	session := ClientSessionFromContext(ctx)
+	if method != "initialize" && method != "ping" &&
+		!session.shared && !session.Initialized() {
+		return createErrorResponse(
+			id,
+			mcp.INVALID_REQUEST,
+			"Session not initialized",
+		), false
+	}
+
+	if response := s.checkRateLimits(ctx, id, method, message); response != nil {
+		return response, false
+	}
+
+	// Requests other than initialize may be cancelled by the client
+	if method == "initialize" {
+		return s.handleRequest(ctx, id, method, message), false
+	}
+	ctx, finish := session.trackRequest(ctx, id)
+	requestStarted(ctx)
+	ctx = s.withProgress(ctx, message)
+	response := s.handleRequest(ctx, id, method, message)
+	ProgressReporterFromContext(ctx).finish()
+	return response, finish()
+}
+
+// handleRequest dispatches a request from the client to its handler
+func (s *MCPServer) handleRequest(
+	ctx context.Context,
+	id interface{},
+	method string,
+	message json.RawMessage,
+) (response mcp.JSONRPCMessage) {
+	// A panicking handler fails its own request only
+	defer func() {
+		if r := recover(); r != nil {
+			stack := debug.Stack()
+			if p, ok := r.(*handlerPanic); ok {
+				r, stack = p.value, p.stack
+			}
+			s.reportPanic(ctx, method, id, r, stack)
+			response = createErrorResponse(id, mcp.INTERNAL_ERROR, "Internal error")
+		}
+	}()
+	ctx = withRequestID(ctx, id)
+
+	switch method {
 	case "initialize":
 		var request mcp.InitializeRequest
 		if err := json.Unmarshal(message, &request); err != nil {
 			return createErrorResponse(
-				baseMessage.ID,
+				id,
 				mcp.INVALID_REQUEST,
 				"Invalid initialize request",
 			)
 		}
-		return s.handleInitialize(ctx, baseMessage.ID, request)
+		return s.handleInitialize(ctx, id, request)
 	case "ping":
 		var request mcp.PingRequest
 		if err := json.Unmarshal(message, &request); err != nil {
 			return createErrorResponse(
-				baseMessage.ID,
+				id,
 				mcp.INVALID_REQUEST,
 				"Invalid ping request",
 			)
 		}
-		return s.handlePing(ctx, baseMessage.ID, request)
+		return s.handlePing(ctx, id, request)
+	case "logging/setLevel":
+		if !s.capabilities.logging {
+			return createErrorResponse(
+				id,
+				mcp.METHOD_NOT_FOUND,
+				"Logging not supported",
+			)
+		}
+		var request mcp.SetLevelRequest
+		if err := json.Unmarshal(message, &request); err != nil {
+			return createErrorResponse(
+				id,
+				mcp.INVALID_REQUEST,
+				"Invalid set level request",
+			)
+		}
+		return s.handleSetLevel(ctx, id, request)
 	case "resources/list":
 		if s.capabilities.resources == nil {
 			return createErrorResponse(
-				baseMessage.ID,
+				id,
 				mcp.METHOD_NOT_FOUND,
 				"Resources not supported",
 			)
@@ -265,16 +453,16 @@ func (s *MCPServer) HandleMessage(
 		var request mcp.ListResourcesRequest
 		if err := json.Unmarshal(message, &request); err != nil {
 			return createErrorResponse(
-				baseMessage.ID,
+				id,
 				mcp.INVALID_REQUEST,
 				"Invalid list resources request",
 			)
 		}
-		return s.handleListResources(ctx, baseMessage.ID, request)
+		return s.handleListResources(ctx, id, request)
 	case "resources/templates/list":
 		if s.capabilities.resources == nil {
 			return createErrorResponse(
-				baseMessage.ID,
+				id,
 				mcp.METHOD_NOT_FOUND,
 				"Resources not supported",
 			)
@@ -282,16 +470,16 @@ func (s *MCPServer) HandleMessage(
 		var request mcp.ListResourceTemplatesRequest
 		if err := json.Unmarshal(message, &request); err != nil {
 			return createErrorResponse(
-				baseMessage.ID,
+				id,
 				mcp.INVALID_REQUEST,
 				"Invalid list resource templates request",
 			)
 		}
-		return s.handleListResourceTemplates(ctx, baseMessage.ID, request)
+		return s.handleListResourceTemplates(ctx, id, request)
 	case "resources/read":
 		if s.capabilities.resources == nil {
 			return createErrorResponse(
-				baseMessage.ID,
+				id,
 				mcp.METHOD_NOT_FOUND,
 				"Resources not supported",
 			)
@@ -299,16 +487,52 @@ func (s *MCPServer) HandleMessage(
 		var request mcp.ReadResourceRequest
 		if err := json.Unmarshal(message, &request); err != nil {
 			return createErrorResponse(
-				baseMessage.ID,
+				id,
 				mcp.INVALID_REQUEST,
 				"Invalid read resource request",
 			)
 		}
-		return s.handleReadResource(ctx, baseMessage.ID, request)
+		return s.handleReadResource(ctx, id, request)
+	case "resources/subscribe":
+		if s.capabilities.resources == nil ||
+			!s.capabilities.resources.subscribe {
+			return createErrorResponse(
+				id,
+				mcp.METHOD_NOT_FOUND,
+				"Resource subscriptions not supported",
+			)
+		}
+		var request mcp.SubscribeRequest
+		if err := json.Unmarshal(message, &request); err != nil {
+			return createErrorResponse(
+				id,
+				mcp.INVALID_REQUEST,
+				"Invalid subscribe request",
+			)
+		}
+		return s.handleSubscribe(ctx, id, request)
+	case "resources/unsubscribe":
+		if s.capabilities.resources == nil ||
+			!s.capabilities.resources.subscribe {
+			return createErrorResponse(
+				id,
+				mcp.METHOD_NOT_FOUND,
+				"Resource subscriptions not supported",
+			)
+		}
+		var request mcp.UnsubscribeRequest
+		if err := json.Unmarshal(message, &request); err != nil {
+			return createErrorResponse(
+				id,
+				mcp.INVALID_REQUEST,
+				"Invalid unsubscribe request",
+			)
+		}
+		return s.handleUnsubscribe(ctx, id, request)
 	case "prompts/list":
 		if s.capabilities.prompts == nil {
 			return createErrorResponse(
-				baseMessage.ID,
+				id,
 				mcp.METHOD_NOT_FOUND,
 				"Prompts not supported",
 			)
@@ -316,16 +540,16 @@ func (s *MCPServer) HandleMessage(
 		var request mcp.ListPromptsRequest
 		if err := json.Unmarshal(message, &request); err != nil {
 			return createErrorResponse(
-				baseMessage.ID,
+				id,
 				mcp.INVALID_REQUEST,
 				"Invalid list prompts request",
 			)
 		}
-		return s.handleListPrompts(ctx, baseMessage.ID, request)
+		return s.handleListPrompts(ctx, id, request)
 	case "prompts/get":
 		if s.capabilities.prompts == nil {
 			return createErrorResponse(
-				baseMessage.ID,
+				id,
 				mcp.METHOD_NOT_FOUND,
 				"Prompts not supported",
 			)
@@ -333,16 +557,16 @@ func (s *MCPServer) HandleMessage(
 		var request mcp.GetPromptRequest
 		if err := json.Unmarshal(message, &request); err != nil {
 			return createErrorResponse(
-				baseMessage.ID,
+				id,
 				mcp.INVALID_REQUEST,
 				"Invalid get prompt request",
 			)
 		}
-		return s.handleGetPrompt(ctx, baseMessage.ID, request)
+		return s.handleGetPrompt(ctx, id, request)
 	case "tools/list":
-		if len(s.tools) == 0 {
+		if !s.toolsSupported() {
 			return createErrorResponse(
-				baseMessage.ID,
+				id,
 				mcp.METHOD_NOT_FOUND,
 				"Tools not supported",
 			)
@@ -350,16 +574,16 @@ func (s *MCPServer) HandleMessage(
 		var request mcp.ListToolsRequest
 		if err := json.Unmarshal(message, &request); err != nil {
 			return createErrorResponse(
-				baseMessage.ID,
+				id,
 				mcp.INVALID_REQUEST,
 				"Invalid list tools request",
 			)
 		}
-		return s.handleListTools(ctx, baseMessage.ID, request)
+		return s.handleListTools(ctx, id, request)
 	case "tools/call":
-		if len(s.tools) == 0 {
+		if !s.toolsSupported() {
 			return createErrorResponse(
-				baseMessage.ID,
+				id,
 				mcp.METHOD_NOT_FOUND,
 				"Tools not supported",
 			)
@@ -367,17 +591,27 @@ func (s *MCPServer) HandleMessage(
 		var request mcp.CallToolRequest
 		if err := json.Unmarshal(message, &request); err != nil {
 			return createErrorResponse(
-				baseMessage.ID,
+				id,
 				mcp.INVALID_REQUEST,
 				"Invalid call tool request",
 			)
 		}
-		return s.handleToolCall(ctx, baseMessage.ID, request)
+		return s.handleToolCall(ctx, id, request)
+	case "completion/complete":
+		var request mcp.CompleteRequest
+		if err := json.Unmarshal(message, &request); err != nil {
+			return createErrorResponse(
+				id,
+				mcp.INVALID_REQUEST,
+				"Invalid complete request",
+			)
+		}
+		return s.handleComplete(ctx, id, request)
 	default:
 		return createErrorResponse(
-			baseMessage.ID,
+			id,
 			mcp.METHOD_NOT_FOUND,
-			fmt.Sprintf("Method %s not found", baseMessage.Method),
+			fmt.Sprintf("Method %s not found", method),
 		)
 	}
 }
@@ -386,13 +620,48 @@ func (s *MCPServer) HandleMessage(
 func (s *MCPServer) AddResource(
 	resource mcp.Resource,
 	handler ResourceHandlerFunc,
+	opts ...ResourceRegistrationOption,
 ) {
 	if s.capabilities.resources == nil {
 		panic("Resource capabilities not enabled")
 	}
-	s.resources[resource.URI] = resourceEntry{
-		resource: resource,
-		handler:  handler,
+	s.mu.Lock()
+	s.resources[resource.URI] = s.newResourceEntry(resource, handler, opts)
+	s.mu.Unlock()
+
+	s.notifyResourceListChanged()
+}
+
+// SetResources atomically replaces all registered resources, sending a single
+// list changed notification
+func (s *MCPServer) SetResources(resources ...ServerResource) {
+	if s.capabilities.resources == nil {
+		panic("Resource capabilities not enabled")
+	}
+	entries := make(map[string]resourceEntry, len(resources))
+	for _, resource := range resources {
+		entries[resource.Resource.URI] = s.newResourceEntry(
+			resource.Resource,
+			resource.Handler,
+			resource.Options,
+		)
+	}
+	s.mu.Lock()
+	s.resources = entries
+	s.mu.Unlock()
+
+	s.notifyResourceListChanged()
+}
+
+// RemoveResource unregisters the resource with the given URI
+func (s *MCPServer) RemoveResource(uri string) {
+	s.mu.Lock()
+	_, ok := s.resources[uri]
+	delete(s.resources, uri)
+	s.mu.Unlock()
+
+	if ok {
+		s.notifyResourceListChanged()
 	}
 }
 
@@ -400,36 +669,184 @@ func (s *MCPServer) AddResource(
 func (s *MCPServer) AddResourceTemplate(
 	template mcp.ResourceTemplate,
 	handler ResourceTemplateHandlerFunc,
+	opts ...ResourceRegistrationOption,
 ) {
 	if s.capabilities.resources == nil {
 		panic("Resource capabilities not enabled")
 	}
-	s.resourceTemplates[template.URITemplate] = resourceTemplateEntry{
-		template: template,
-		handler:  handler,
+	s.mu.Lock()
+	s.resourceTemplates[template.URITemplate] = s.newResourceTemplateEntry(
+		template,
+		handler,
+		opts,
+	)
+	s.mu.Unlock()
+
+	s.notifyResourceListChanged()
+}
+
+// SetResourceTemplates atomically replaces all registered resource
+// templates, sending a single list changed notification
+func (s *MCPServer) SetResourceTemplates(templates ...ServerResourceTemplate) {
+	if s.capabilities.resources == nil {
+		panic("Resource capabilities not enabled")
+	}
+	entries := make(map[string]resourceTemplateEntry, len(templates))
+	for _, template := range templates {
+		entries[template.Template.URITemplate] = s.newResourceTemplateEntry(
+			template.Template,
+			template.Handler,
+			template.Options,
+		)
+	}
+	s.mu.Lock()
+	s.resourceTemplates = entries
+	s.mu.Unlock()
+
+	s.notifyResourceListChanged()
+}
+
+// RemoveResourceTemplate unregisters the resource template with the given
+// URI template
+func (s *MCPServer) RemoveResourceTemplate(uriTemplate string) {
+	s.mu.Lock()
+	_, ok := s.resourceTemplates[uriTemplate]
+	delete(s.resourceTemplates, uriTemplate)
+	s.mu.Unlock()
+
+	if ok {
+		s.notifyResourceListChanged()
 	}
 }
 
 // AddPrompt registers a new prompt handler with the given name
-func (s *MCPServer) AddPrompt(prompt mcp.Prompt, handler PromptHandlerFunc) {
+func (s *MCPServer) AddPrompt(
+	prompt mcp.Prompt,
+	handler PromptHandlerFunc,
+	opts ...PromptRegistrationOption,
+) {
 	if s.capabilities.prompts == nil {
 		panic("Prompt capabilities not enabled")
 	}
-	s.prompts[prompt.Name] = prompt
-	s.promptHandlers[prompt.Name] = handler
+	s.mu.Lock()
+	s.prompts[prompt.Name] = s.newPromptEntry(prompt, handler, opts)
+	s.mu.Unlock()
+
+	s.notifyPromptListChanged()
+}
+
+// SetPrompts atomically replaces all registered prompts, sending a single
+// list changed notification
+func (s *MCPServer) SetPrompts(prompts ...ServerPrompt) {
+	if s.capabilities.prompts == nil {
+		panic("Prompt capabilities not enabled")
+	}
+	entries := make(map[string]promptEntry, len(prompts))
+	for _, prompt := range prompts {
+		entries[prompt.Prompt.Name] = s.newPromptEntry(
+			prompt.Prompt,
+			prompt.Handler,
+			prompt.Options,
+		)
+	}
+	s.mu.Lock()
+	s.prompts = entries
+	s.mu.Unlock()
+
+	s.notifyPromptListChanged()
+}
+
+// RemovePrompt unregisters the prompt with the given name
+func (s *MCPServer) RemovePrompt(name string) {
+	s.mu.Lock()
+	_, ok := s.prompts[name]
+	delete(s.prompts, name)
+	s.mu.Unlock()
+
+	if ok {
+		s.notifyPromptListChanged()
+	}
 }
 
 // AddTool registers a new tool and its handler
-func (s *MCPServer) AddTool(tool mcp.Tool, handler ToolHandlerFunc) {
-	s.tools[tool.Name] = tool
-	s.toolHandlers[tool.Name] = handler
+func (s *MCPServer) AddTool(
+	tool mcp.Tool,
+	handler ToolHandlerFunc,
+	opts ...ToolRegistrationOption,
+) {
+	s.mu.Lock()
+	s.tools[tool.Name] = s.newToolEntry(tool, handler, opts)
+	s.toolsRegistered = true
+	s.mu.Unlock()
 
-	// Send notification if server is already initialized
-	if s.initialized {
-		if err := s.SendNotificationToClient("notifications/tools/list_changed", nil); err != nil {
-			// We can't return the error, but in a future version we could log it
-		}
+	s.notifyToolListChanged()
+}
+
+// SetTools atomically replaces all registered tools, sending a single list
+// changed notification
+func (s *MCPServer) SetTools(tools ...ServerTool) {
+	entries := make(map[string]toolEntry, len(tools))
+	for _, tool := range tools {
+		entries[tool.Tool.Name] = s.newToolEntry(tool.Tool, tool.Handler, tool.Options)
+	}
+	s.mu.Lock()
+	s.tools = entries
+	if len(entries) > 0 {
+		s.toolsRegistered = true
+	}
+	s.mu.Unlock()
+
+	s.notifyToolListChanged()
+}
+
+// RemoveTool unregisters the tool with the given name. Calls already in
+// flight complete normally; subsequent calls fail as for an unknown tool.
+func (s *MCPServer) RemoveTool(name string) {
+	s.mu.Lock()
+	_, ok := s.tools[name]
+	delete(s.tools, name)
+	s.mu.Unlock()
+
+	if ok {
+		s.notifyToolListChanged()
+	}
+}
+
+// notifyToolListChanged tells every initialized client that the tool list
+// changed, unless tool list changes are not advertised
+func (s *MCPServer) notifyToolListChanged() {
+	if s.capabilities.tools != nil && !s.capabilities.tools.listChanged {
+		return
+	}
+	s.notifyInitializedSessions("notifications/tools/list_changed", nil)
+}
+
+// notifyResourceListChanged tells every initialized client that the resource
+// list changed, if resource list changes are advertised
+func (s *MCPServer) notifyResourceListChanged() {
+	if s.capabilities.resources.listChanged {
+		s.notifyInitializedSessions("notifications/resources/list_changed", nil)
+	}
+}
+
+// notifyPromptListChanged tells every initialized client that the prompt list
+// changed, if prompt list changes are advertised
+func (s *MCPServer) notifyPromptListChanged() {
+	if s.capabilities.prompts.listChanged {
+		s.notifyInitializedSessions("notifications/prompts/list_changed", nil)
+	}
+}
+
+// toolsSupported reports whether tools were enabled with
+// WithToolCapabilities or any tool was ever registered. Removing all tools
+// does not withdraw support, which clients may already have been told about.
+func (s *MCPServer) toolsSupported() bool {
+	if s.capabilities.tools != nil {
+		return true
 	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.toolsRegistered
 }
 
 // AddNotificationHandler registers a new handler for incoming notifications
@@ -437,6 +854,8 @@ func (s *MCPServer) AddNotificationHandler(
 	method string,
 	handler NotificationHandlerFunc,
 ) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.notificationHandlers[method] = handler
 }
 
@@ -445,43 +864,78 @@ func (s *MCPServer) handleInitialize(
 	id interface{},
 	request mcp.InitializeRequest,
 ) mcp.JSONRPCMessage {
-	capabilities := mcp.ServerCapabilities{}
+	result := mcp.InitializeResult{
+		ProtocolVersion: s.negotiateProtocolVersion(request.Params.ProtocolVersion),
+		ServerInfo: mcp.Implementation{
+			Name:    s.name,
+			Version: s.version,
+		},
+		Capabilities: s.advertisedCapabilities(),
+		Instructions: s.instructions,
+	}
+
+	session := ClientSessionFromContext(ctx)
+	if !session.initialize(request, result.ProtocolVersion) {
+		return createErrorResponse(
+			id,
+			mcp.INVALID_REQUEST,
+			"Session already initialized",
+		)
+	}
+	s.onInitialize(ctx, session, request, &result)
+	return createResponse(id, result)
+}
 
-	capabilities.Resources = &struct {
-		Subscribe   bool `json:"subscribe,omitempty"`
-		ListChanged bool `json:"listChanged,omitempty"`
-	}{
-		Subscribe:   false,
-		ListChanged: true,
+// advertisedCapabilities describes the features enabled by the server options
+// and registrations, omitting everything the server does not support
+func (s *MCPServer) advertisedCapabilities() mcp.ServerCapabilities {
+	capabilities := mcp.ServerCapabilities{
+		Experimental: s.capabilities.experimental,
 	}
 
-	capabilities.Prompts = &struct {
-		ListChanged bool `json:"listChanged,omitempty"`
-	}{
-		ListChanged: true,
+	if s.capabilities.resources != nil {
+		capabilities.Resources = &struct {
+			Subscribe   bool `json:"subscribe,omitempty"`
+			ListChanged bool `json:"listChanged,omitempty"`
+		}{
+			Subscribe:   s.capabilities.resources.subscribe,
+			ListChanged: s.capabilities.resources.listChanged,
+		}
 	}
 
-	capabilities.Tools = &struct {
-		ListChanged bool `json:"listChanged,omitempty"`
-	}{
-		ListChanged: true,
+	if s.capabilities.prompts != nil {
+		capabilities.Prompts = &struct {
+			ListChanged bool `json:"listChanged,omitempty"`
+		}{
+			ListChanged: s.capabilities.prompts.listChanged,
+		}
+	}
+
+	if s.toolsSupported() {
+		capabilities.Tools = &struct {
+			ListChanged bool `json:"listChanged,omitempty"`
+		}{
+			ListChanged: s.capabilities.tools == nil ||
+				s.capabilities.tools.listChanged,
+		}
 	}
 
 	if s.capabilities.logging {
 		capabilities.Logging = &struct{}{}
 	}
 
-	result := mcp.InitializeResult{
-		ProtocolVersion: mcp.LATEST_PROTOCOL_VERSION,
-		ServerInfo: mcp.Implementation{
-			Name:    s.name,
-			Version: s.version,
-		},
-		Capabilities: capabilities,
-	}
+	return capabilities
+}
 
-	s.initialized = true
-	return createResponse(id, result)
+// negotiateProtocolVersion returns the version requested by the client if the
+// server supports it, and the server's preferred version otherwise
+func (s *MCPServer) negotiateProtocolVersion(requested string) string {
+	for _, version := range s.protocolVersions {
+		if version == requested {
+			return version
+		}
+	}
+	return s.protocolVersions[0]
 }
 
 func (s *MCPServer) handlePing(
@@ -497,17 +951,28 @@ func (s *MCPServer) handleListResources(
 	id interface{},
 	request mcp.ListResourcesRequest,
 ) mcp.JSONRPCMessage {
+	s.mu.RLock()
 	resources := make([]mcp.Resource, 0, len(s.resources))
 	for _, entry := range s.resources {
 		resources = append(resources, entry.resource)
 	}
+	s.mu.RUnlock()
+
+	page, nextCursor, err := paginate(
+		"resources",
+		resources,
+		func(resource mcp.Resource) string { return resource.URI },
+		request.Params.Cursor,
+		s.pageSize,
+	)
+	if err != nil {
+		return createErrorResponse(id, mcp.INVALID_PARAMS, err.Error())
+	}
 
 	result := mcp.ListResourcesResult{
-		Resources: resources,
-	}
-	if request.Params.Cursor != "" {
-		result.NextCursor = "" // Handle pagination if needed
+		Resources: page,
 	}
+	result.NextCursor = nextCursor
 	return createResponse(id, result)
 }
 
@@ -516,17 +981,30 @@ func (s *MCPServer) handleListResourceTemplates(
 	id interface{},
 	request mcp.ListResourceTemplatesRequest,
 ) mcp.JSONRPCMessage {
+	s.mu.RLock()
 	templates := make([]mcp.ResourceTemplate, 0, len(s.resourceTemplates))
 	for _, entry := range s.resourceTemplates {
 		templates = append(templates, entry.template)
 	}
+	s.mu.RUnlock()
 
-	result := mcp.ListResourceTemplatesResult{
-		ResourceTemplates: templates,
+	page, nextCursor, err := paginate(
+		"resourceTemplates",
+		templates,
+		func(template mcp.ResourceTemplate) string {
+			return template.URITemplate
+		},
+		request.Params.Cursor,
+		s.pageSize,
+	)
+	if err != nil {
+		return createErrorResponse(id, mcp.INVALID_PARAMS, err.Error())
 	}
-	if request.Params.Cursor != "" {
-		result.NextCursor = "" // Handle pagination if needed
+
+	result := mcp.ListResourceTemplatesResult{
+		ResourceTemplates: page,
 	}
+	result.NextCursor = nextCursor
 	return createResponse(id, result)
 }
 
@@ -536,50 +1014,67 @@ func (s *MCPServer) handleReadResource(
 	request mcp.ReadResourceRequest,
 ) mcp.JSONRPCMessage {
 	// First try direct resource handlers
-	if entry, ok := s.resources[request.Params.URI]; ok {
+	s.mu.RLock()
+	entry, ok := s.resources[request.Params.URI]
+	s.mu.RUnlock()
+	if ok {
 		contents, err := entry.handler(ctx, request)
 		if err != nil {
-			return createErrorResponse(id, mcp.INTERNAL_ERROR, err.Error())
+			return mcp.NewJSONRPCErrorFromError(id, err)
 		}
 		return createResponse(id, mcp.ReadResourceResult{Contents: contents})
 	}
 
 	// If no direct handler found, try matching against templates
-	for uriTemplate, entry := range s.resourceTemplates {
-		if matchesTemplate(request.Params.URI, uriTemplate) {
-			contents, err := entry.handler(ctx, request)
-			if err != nil {
-				return createErrorResponse(id, mcp.INTERNAL_ERROR, err.Error())
-			}
-			return createResponse(
-				id,
-				mcp.ReadResourceResult{Contents: contents},
-			)
+	if entry, values, ok := s.matchResourceTemplate(request.Params.URI); ok {
+		arguments := make(map[string]interface{}, len(values))
+		for name, value := range request.Params.Arguments {
+			arguments[name] = value
+		}
+		for name, value := range values {
+			arguments[name] = value
+		}
+		request.Params.Arguments = arguments
+
+		contents, err := entry.handler(ctx, request)
+		if err != nil {
+			return mcp.NewJSONRPCErrorFromError(id, err)
 		}
+		return createResponse(
+			id,
+			mcp.ReadResourceResult{Contents: contents},
+		)
 	}
 
-	return createErrorResponse(
+	return mcp.NewJSONRPCError(
 		id,
-		mcp.INVALID_PARAMS,
-		fmt.Sprintf(
-			"No handler found for resource URI: %s",
-			request.Params.URI,
-		),
+		mcp.RESOURCE_NOT_FOUND,
+		fmt.Sprintf("Resource not found: %s", request.Params.URI),
+		map[string]interface{}{"uri": request.Params.URI},
 	)
 }
 
-// matchesTemplate checks if a URI matches a URI template pattern
-func matchesTemplate(uri string, template string) bool {
-	// Convert template into a regex pattern
-	pattern := template
-	// Replace {name} with ([^/]+)
-	pattern = regexp.QuoteMeta(pattern)
-	pattern = regexp.MustCompile(`\\\{[^}]+\\\}`).
-		ReplaceAllString(pattern, `([^/]+)`)
-	pattern = "^" + pattern + "$"
-
-	matched, _ := regexp.MatchString(pattern, uri)
-	return matched
+// matchResourceTemplate returns the most specific registered resource
+// template matching uri, along with the values of its variables
+func (s *MCPServer) matchResourceTemplate(
+	uri string,
+) (resourceTemplateEntry, map[string]interface{}, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var best resourceTemplateEntry
+	var bestValues map[string]interface{}
+	for _, entry := range s.resourceTemplates {
+		if entry.uriTemplate == nil {
+			continue
+		}
+		if best.uriTemplate != nil && !entry.uriTemplate.MoreSpecific(best.uriTemplate) {
+			continue
+		}
+		if values, ok := entry.uriTemplate.Match(uri); ok {
+			best, bestValues = entry, values
+		}
+	}
+	return best, bestValues, best.uriTemplate != nil
 }
 
 func (s *MCPServer) handleListPrompts(
@@ -587,17 +1082,28 @@ func (s *MCPServer) handleListPrompts(
 	id interface{},
 	request mcp.ListPromptsRequest,
 ) mcp.JSONRPCMessage {
+	s.mu.RLock()
 	prompts := make([]mcp.Prompt, 0, len(s.prompts))
-	for _, prompt := range s.prompts {
-		prompts = append(prompts, prompt)
+	for _, entry := range s.prompts {
+		prompts = append(prompts, entry.prompt)
+	}
+	s.mu.RUnlock()
+
+	page, nextCursor, err := paginate(
+		"prompts",
+		prompts,
+		func(prompt mcp.Prompt) string { return prompt.Name },
+		request.Params.Cursor,
+		s.pageSize,
+	)
+	if err != nil {
+		return createErrorResponse(id, mcp.INVALID_PARAMS, err.Error())
 	}
 
 	result := mcp.ListPromptsResult{
-		Prompts: prompts,
-	}
-	if request.Params.Cursor != "" {
-		result.NextCursor = "" // Handle pagination if needed
+		Prompts: page,
 	}
+	result.NextCursor = nextCursor
 	return createResponse(id, result)
 }
 
@@ -606,7 +1112,9 @@ func (s *MCPServer) handleGetPrompt(
 	id interface{},
 	request mcp.GetPromptRequest,
 ) mcp.JSONRPCMessage {
-	handler, ok := s.promptHandlers[request.Params.Name]
+	s.mu.RLock()
+	entry, ok := s.prompts[request.Params.Name]
+	s.mu.RUnlock()
 	if !ok {
 		return createErrorResponse(
 			id,
@@ -615,9 +1123,9 @@ func (s *MCPServer) handleGetPrompt(
 		)
 	}
 
-	result, err := handler(ctx, request)
+	result, err := entry.handler(ctx, request)
 	if err != nil {
-		return createErrorResponse(id, mcp.INTERNAL_ERROR, err.Error())
+		return mcp.NewJSONRPCErrorFromError(id, err)
 	}
 
 	return createResponse(id, result)
@@ -628,17 +1136,28 @@ func (s *MCPServer) handleListTools(
 	id interface{},
 	request mcp.ListToolsRequest,
 ) mcp.JSONRPCMessage {
+	s.mu.RLock()
 	tools := make([]mcp.Tool, 0, len(s.tools))
-	for name := range s.tools {
-		tools = append(tools, s.tools[name])
+	for _, entry := range s.tools {
+		tools = append(tools, entry.tool)
+	}
+	s.mu.RUnlock()
+
+	page, nextCursor, err := paginate(
+		"tools",
+		tools,
+		func(tool mcp.Tool) string { return tool.Name },
+		request.Params.Cursor,
+		s.pageSize,
+	)
+	if err != nil {
+		return createErrorResponse(id, mcp.INVALID_PARAMS, err.Error())
 	}
 
 	result := mcp.ListToolsResult{
-		Tools: tools,
-	}
-	if request.Params.Cursor != "" {
-		result.NextCursor = "" // Handle pagination if needed
+		Tools: page,
 	}
+	result.NextCursor = nextCursor
 	return createResponse(id, result)
 }
 
@@ -647,7 +1166,9 @@ func (s *MCPServer) handleToolCall(
 	id interface{},
 	request mcp.CallToolRequest,
 ) mcp.JSONRPCMessage {
-	handler, ok := s.toolHandlers[request.Params.Name]
+	s.mu.RLock()
+	entry, ok := s.tools[request.Params.Name]
+	s.mu.RUnlock()
 	if !ok {
 		return createErrorResponse(
 			id,
@@ -656,9 +1177,9 @@ func (s *MCPServer) handleToolCall(
 		)
 	}
 
-	result, err := handler(ctx, request)
+	result, err := entry.handler(ctx, request)
 	if err != nil {
-		return createErrorResponse(id, mcp.INTERNAL_ERROR, err.Error())
+		return mcp.NewJSONRPCErrorFromError(id, err)
 	}
 
 	return createResponse(id, result)
@@ -668,7 +1189,25 @@ func (s *MCPServer) handleNotification(
 	ctx context.Context,
 	notification mcp.JSONRPCNotification,
 ) mcp.JSONRPCMessage {
-	if handler, ok := s.notificationHandlers[notification.Method]; ok {
+	defer func() {
+		if r := recover(); r != nil {
+			s.reportPanic(ctx, notification.Method, nil, r, debug.Stack())
+		}
+	}()
+
+	switch notification.Method {
+	case "notifications/initialized":
+		ClientSessionFromContext(ctx).markReady()
+	case "notifications/cancelled":
+		s.handleCancelled(ctx, notification)
+	case "notifications/roots/list_changed":
+		s.handleRootsListChanged(ctx)
+	}
+
+	s.mu.RLock()
+	handler, ok := s.notificationHandlers[notification.Method]
+	s.mu.RUnlock()
+	if ok {
 		handler(ctx, notification)
 	}
 	return nil
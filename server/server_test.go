@@ -1,12 +1,17 @@
 package server
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
+	"log"
 	"testing"
+	"time"
 
 	"github.com/shaneholloman/mcp-server-go/mcp"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMCPServer_NewMCPServer(t *testing.T) {
@@ -39,14 +44,12 @@ func TestMCPServer_Capabilities(t *testing.T) {
 				)
 				assert.Equal(t, "test-server", initResult.ServerInfo.Name)
 				assert.Equal(t, "1.0.0", initResult.ServerInfo.Version)
-				assert.NotNil(t, initResult.Capabilities.Resources)
-				assert.False(t, initResult.Capabilities.Resources.Subscribe)
-				assert.True(t, initResult.Capabilities.Resources.ListChanged)
-				assert.NotNil(t, initResult.Capabilities.Prompts)
-				assert.True(t, initResult.Capabilities.Prompts.ListChanged)
-				assert.NotNil(t, initResult.Capabilities.Tools)
-				assert.True(t, initResult.Capabilities.Tools.ListChanged)
+				assert.Nil(t, initResult.Capabilities.Resources)
+				assert.Nil(t, initResult.Capabilities.Prompts)
+				assert.Nil(t, initResult.Capabilities.Tools)
 				assert.Nil(t, initResult.Capabilities.Logging)
+				assert.Nil(t, initResult.Capabilities.Experimental)
+				assert.Empty(t, initResult.Instructions)
 			},
 		},
 		{
@@ -54,6 +57,7 @@ func TestMCPServer_Capabilities(t *testing.T) {
 			options: []ServerOption{
 				WithResourceCapabilities(true, true),
 				WithPromptCapabilities(true),
+				WithToolCapabilities(true),
 				WithLogging(),
 			},
 			validate: func(t *testing.T, response mcp.JSONRPCMessage) {
@@ -72,8 +76,7 @@ func TestMCPServer_Capabilities(t *testing.T) {
 				assert.Equal(t, "1.0.0", initResult.ServerInfo.Version)
 
 				assert.NotNil(t, initResult.Capabilities.Resources)
-				// Resources capabilities are now always false for subscribe and true for listChanged
-				assert.False(t, initResult.Capabilities.Resources.Subscribe)
+				assert.True(t, initResult.Capabilities.Resources.Subscribe)
 				assert.True(t, initResult.Capabilities.Resources.ListChanged)
 
 				assert.NotNil(t, initResult.Capabilities.Prompts)
@@ -85,6 +88,56 @@ func TestMCPServer_Capabilities(t *testing.T) {
 				assert.NotNil(t, initResult.Capabilities.Logging)
 			},
 		},
+		{
+			name: "Capabilities without list changes",
+			options: []ServerOption{
+				WithResourceCapabilities(false, false),
+				WithPromptCapabilities(false),
+				WithToolCapabilities(false),
+			},
+			validate: func(t *testing.T, response mcp.JSONRPCMessage) {
+				resp, ok := response.(mcp.JSONRPCResponse)
+				assert.True(t, ok)
+
+				initResult, ok := resp.Result.(mcp.InitializeResult)
+				assert.True(t, ok)
+
+				assert.NotNil(t, initResult.Capabilities.Resources)
+				assert.False(t, initResult.Capabilities.Resources.Subscribe)
+				assert.False(t, initResult.Capabilities.Resources.ListChanged)
+
+				assert.NotNil(t, initResult.Capabilities.Prompts)
+				assert.False(t, initResult.Capabilities.Prompts.ListChanged)
+
+				assert.NotNil(t, initResult.Capabilities.Tools)
+				assert.False(t, initResult.Capabilities.Tools.ListChanged)
+			},
+		},
+		{
+			name: "Experimental capabilities and instructions",
+			options: []ServerOption{
+				WithExperimental("streaming", map[string]interface{}{"chunked": true}),
+				WithExperimental("tracing", struct{}{}),
+				WithInstructions("Use the search tool first."),
+			},
+			validate: func(t *testing.T, response mcp.JSONRPCMessage) {
+				resp, ok := response.(mcp.JSONRPCResponse)
+				assert.True(t, ok)
+
+				initResult, ok := resp.Result.(mcp.InitializeResult)
+				assert.True(t, ok)
+
+				assert.Equal(
+					t,
+					map[string]interface{}{
+						"streaming": map[string]interface{}{"chunked": true},
+						"tracing":   struct{}{},
+					},
+					initResult.Capabilities.Experimental,
+				)
+				assert.Equal(t, "Use the search tool first.", initResult.Instructions)
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -200,7 +253,7 @@ func TestMCPServer_HandlePagination(t *testing.T) {
 		validate func(t *testing.T, response mcp.JSONRPCMessage)
 	}{
 		{
-			name: "List resources with cursor",
+			name: "List resources with invalid cursor",
 			message: `{
                     "jsonrpc": "2.0",
                     "id": 1,
@@ -208,6 +261,19 @@ func TestMCPServer_HandlePagination(t *testing.T) {
                     "params": {
                         "cursor": "test-cursor"
                     }
+                }`,
+			validate: func(t *testing.T, response mcp.JSONRPCMessage) {
+				errorResponse, ok := response.(mcp.JSONRPCError)
+				assert.True(t, ok)
+				assert.Equal(t, mcp.INVALID_PARAMS, errorResponse.Error.Code)
+			},
+		},
+		{
+			name: "List resources without page size",
+			message: `{
+                    "jsonrpc": "2.0",
+                    "id": 1,
+                    "method": "resources/list"
                 }`,
 			validate: func(t *testing.T, response mcp.JSONRPCMessage) {
 				resp, ok := response.(mcp.JSONRPCResponse)
@@ -215,7 +281,7 @@ func TestMCPServer_HandlePagination(t *testing.T) {
 
 				listResult, ok := resp.Result.(mcp.ListResourcesResult)
 				assert.True(t, ok)
-				assert.NotNil(t, listResult.Resources)
+				assert.Len(t, listResult.Resources, 1)
 				assert.Equal(t, mcp.Cursor(""), listResult.NextCursor)
 			},
 		},
@@ -476,7 +542,7 @@ func TestMCPServer_HandleUndefinedHandlers(t *testing.T) {
                         "uri": "undefined-resource"
                     }
                 }`,
-			expectedErr: mcp.INVALID_PARAMS,
+			expectedErr: mcp.RESOURCE_NOT_FOUND,
 		},
 	}
 
@@ -602,3 +668,400 @@ func createTestServer() *MCPServer {
 
 	return server
 }
+
+func TestMCPServer_ToolCapabilitiesFollowRegistrations(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0")
+	initialize := func() mcp.ServerCapabilities {
+		response := server.HandleMessage(context.Background(), []byte(initializeMessage))
+		resp, ok := response.(mcp.JSONRPCResponse)
+		require.True(t, ok)
+		return resp.Result.(mcp.InitializeResult).Capabilities
+	}
+
+	assert.Nil(t, initialize().Tools)
+
+	server.AddTool(mcp.NewTool("test-tool"), nil)
+	require.NotNil(t, initialize().Tools)
+	assert.True(t, initialize().Tools.ListChanged)
+
+	// Support is not withdrawn when the last tool is removed
+	server.RemoveTool("test-tool")
+	assert.NotNil(t, initialize().Tools)
+	response := server.HandleMessage(context.Background(), []byte(`{
+        "jsonrpc": "2.0",
+        "id": 1,
+        "method": "tools/list"
+    }`))
+	assert.IsType(t, mcp.JSONRPCResponse{}, response)
+}
+
+func TestMCPServer_EmptyLists(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0",
+		WithResourceCapabilities(false, false),
+		WithPromptCapabilities(false),
+		WithToolCapabilities(false),
+	)
+
+	tests := []struct {
+		method string
+		field  string
+	}{
+		{method: "resources/list", field: "resources"},
+		{method: "resources/templates/list", field: "resourceTemplates"},
+		{method: "prompts/list", field: "prompts"},
+		{method: "tools/list", field: "tools"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			response := server.HandleMessage(context.Background(), []byte(fmt.Sprintf(
+				`{"jsonrpc": "2.0", "id": 1, "method": %q}`,
+				tt.method,
+			)))
+			resp, ok := response.(mcp.JSONRPCResponse)
+			require.True(t, ok)
+
+			data, err := json.Marshal(resp.Result)
+			require.NoError(t, err)
+			var result map[string]interface{}
+			require.NoError(t, json.Unmarshal(data, &result))
+			assert.Equal(t, []interface{}{}, result[tt.field])
+		})
+	}
+}
+
+func TestMCPServer_ToolListChangedNotAdvertised(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0", WithToolCapabilities(false))
+	_, session := newReadySession(t, server, "session-1")
+
+	server.AddTool(mcp.NewTool("test-tool"), nil)
+	server.RemoveTool("test-tool")
+	assert.Empty(t, drainNotifications(session))
+}
+
+func TestMCPServer_ListChangedNotifications(t *testing.T) {
+	resourceHandler := func(ctx context.Context, request mcp.ReadResourceRequest) ([]interface{}, error) {
+		return []interface{}{}, nil
+	}
+	promptHandler := func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		return &mcp.GetPromptResult{}, nil
+	}
+	toolHandler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("done"), nil
+	}
+
+	tests := []struct {
+		name     string
+		action   func(server *MCPServer)
+		expected []string
+	}{
+		{
+			name: "AddResource",
+			action: func(server *MCPServer) {
+				server.AddResource(mcp.NewResource("test://new", "New"), resourceHandler)
+			},
+			expected: []string{"notifications/resources/list_changed"},
+		},
+		{
+			name: "RemoveResource",
+			action: func(server *MCPServer) {
+				server.RemoveResource("test://static")
+			},
+			expected: []string{"notifications/resources/list_changed"},
+		},
+		{
+			name: "RemoveResource unknown",
+			action: func(server *MCPServer) {
+				server.RemoveResource("test://unknown")
+			},
+		},
+		{
+			name: "RemoveResourceTemplate",
+			action: func(server *MCPServer) {
+				server.RemoveResourceTemplate("test://items/{id}")
+			},
+			expected: []string{"notifications/resources/list_changed"},
+		},
+		{
+			name: "SetResources",
+			action: func(server *MCPServer) {
+				server.SetResources(
+					ServerResource{Resource: mcp.NewResource("test://a", "A"), Handler: resourceHandler},
+					ServerResource{Resource: mcp.NewResource("test://b", "B"), Handler: resourceHandler},
+				)
+			},
+			expected: []string{"notifications/resources/list_changed"},
+		},
+		{
+			name: "SetResourceTemplates",
+			action: func(server *MCPServer) {
+				server.SetResourceTemplates(ServerResourceTemplate{
+					Template: mcp.NewResourceTemplate("test://users/{id}", "User"),
+					Handler:  resourceHandler,
+				})
+			},
+			expected: []string{"notifications/resources/list_changed"},
+		},
+		{
+			name: "RemovePrompt",
+			action: func(server *MCPServer) {
+				server.RemovePrompt("test-prompt")
+			},
+			expected: []string{"notifications/prompts/list_changed"},
+		},
+		{
+			name: "SetPrompts",
+			action: func(server *MCPServer) {
+				server.SetPrompts(
+					ServerPrompt{Prompt: mcp.Prompt{Name: "a"}, Handler: promptHandler},
+					ServerPrompt{Prompt: mcp.Prompt{Name: "b"}, Handler: promptHandler},
+				)
+			},
+			expected: []string{"notifications/prompts/list_changed"},
+		},
+		{
+			name: "SetTools",
+			action: func(server *MCPServer) {
+				server.SetTools(
+					ServerTool{Tool: mcp.NewTool("a"), Handler: toolHandler},
+					ServerTool{Tool: mcp.NewTool("b"), Handler: toolHandler},
+					ServerTool{Tool: mcp.NewTool("c"), Handler: toolHandler},
+				)
+			},
+			expected: []string{"notifications/tools/list_changed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := NewMCPServer("test-server", "1.0.0",
+				WithResourceCapabilities(false, true),
+				WithPromptCapabilities(true),
+			)
+			server.AddResource(mcp.NewResource("test://static", "Static"), resourceHandler)
+			server.AddResourceTemplate(
+				mcp.NewResourceTemplate("test://items/{id}", "Item"),
+				resourceHandler,
+			)
+			server.AddPrompt(mcp.Prompt{Name: "test-prompt"}, promptHandler)
+			server.AddTool(mcp.NewTool("test-tool"), toolHandler)
+
+			_, first := newReadySession(t, server, "session-1")
+			_, second := newReadySession(t, server, "session-2")
+			_, uninitialized := newTestSession(t, server, "session-3")
+			initializingCtx, initializing := newTestSession(t, server, "session-4")
+			require.IsType(
+				t,
+				mcp.JSONRPCResponse{},
+				server.HandleMessage(initializingCtx, []byte(initializeMessage)),
+			)
+
+			tt.action(server)
+
+			for _, session := range []*ClientSession{first, second} {
+				var methods []string
+				for _, notification := range drainNotifications(session) {
+					methods = append(methods, notification.Method)
+				}
+				assert.Equal(t, tt.expected, methods)
+			}
+			assert.Empty(t, drainNotifications(uninitialized))
+			assert.Empty(t, drainNotifications(initializing))
+		})
+	}
+}
+
+func TestMCPServer_SetReplacesRegistrations(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0", WithPromptCapabilities(false))
+	toolHandler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText(request.Params.Name), nil
+	}
+	server.AddTool(mcp.NewTool("old"), toolHandler)
+	server.AddPrompt(mcp.Prompt{Name: "old"}, nil)
+	_, session := newReadySession(t, server, "session-1")
+
+	server.SetTools(
+		ServerTool{Tool: mcp.NewTool("new-1"), Handler: toolHandler},
+		ServerTool{Tool: mcp.NewTool("new-2"), Handler: toolHandler},
+	)
+	server.SetPrompts()
+
+	response := server.HandleMessage(context.Background(), []byte(`{
+        "jsonrpc": "2.0",
+        "id": 1,
+        "method": "tools/list"
+    }`))
+	resp, ok := response.(mcp.JSONRPCResponse)
+	require.True(t, ok)
+	tools := resp.Result.(mcp.ListToolsResult).Tools
+	require.Len(t, tools, 2)
+	assert.Equal(t, "new-1", tools[0].Name)
+	assert.Equal(t, "new-2", tools[1].Name)
+
+	response = server.HandleMessage(context.Background(), callToolMessage(2, "old"))
+	assert.IsType(t, mcp.JSONRPCError{}, response)
+
+	response = server.HandleMessage(context.Background(), []byte(`{
+        "jsonrpc": "2.0",
+        "id": 3,
+        "method": "prompts/list"
+    }`))
+	resp, ok = response.(mcp.JSONRPCResponse)
+	require.True(t, ok)
+	assert.Empty(t, resp.Result.(mcp.ListPromptsResult).Prompts)
+
+	// Prompt list changes are not advertised, so only the tools are announced
+	notifications := drainNotifications(session)
+	require.Len(t, notifications, 1)
+	assert.Equal(t, "notifications/tools/list_changed", notifications[0].Method)
+}
+
+func TestMCPServer_HandlerErrors(t *testing.T) {
+	data := map[string]interface{}{"field": "city"}
+
+	tests := []struct {
+		name            string
+		err             error
+		expectedCode    int
+		expectedMessage string
+		expectedData    interface{}
+	}{
+		{
+			name:            "Plain error",
+			err:             fmt.Errorf("database unavailable"),
+			expectedCode:    mcp.INTERNAL_ERROR,
+			expectedMessage: "database unavailable",
+		},
+		{
+			name:            "mcp.Error",
+			err:             mcp.NewError(mcp.INVALID_PARAMS, "unknown city", data),
+			expectedCode:    mcp.INVALID_PARAMS,
+			expectedMessage: "unknown city",
+			expectedData:    data,
+		},
+		{
+			name: "Wrapped mcp.Error",
+			err: fmt.Errorf(
+				"lookup failed: %w",
+				mcp.NewError(mcp.RESOURCE_NOT_FOUND, "not found", nil),
+			),
+			expectedCode:    mcp.RESOURCE_NOT_FOUND,
+			expectedMessage: "not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hookErr error
+			server := NewMCPServer("test-server", "1.0.0", WithHooks(&Hooks{
+				AfterRequest: func(
+					ctx context.Context,
+					method string,
+					id interface{},
+					result interface{},
+					err error,
+					duration time.Duration,
+				) {
+					hookErr = err
+				},
+			}))
+			server.AddTool(
+				mcp.NewTool("failing"),
+				func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+					return nil, tt.err
+				},
+			)
+
+			response := server.HandleMessage(
+				context.Background(),
+				callToolMessage(1, "failing"),
+			)
+			errorResponse, ok := response.(mcp.JSONRPCError)
+			require.True(t, ok)
+			assert.Equal(t, tt.expectedCode, errorResponse.Error.Code)
+			assert.Equal(t, tt.expectedMessage, errorResponse.Error.Message)
+			assert.Equal(t, tt.expectedData, errorResponse.Error.Data)
+
+			var rpcErr *mcp.Error
+			require.ErrorAs(t, hookErr, &rpcErr)
+			assert.Equal(t, tt.expectedCode, rpcErr.Code)
+		})
+	}
+}
+
+func TestMCPServer_ReadResourceTemplates(t *testing.T) {
+	var errLog bytes.Buffer
+	server := NewMCPServer("test-server", "1.0.0",
+		WithResourceCapabilities(false, false),
+		WithErrorLogger(log.New(&errLog, "", 0)),
+	)
+	templateHandler := func(name string) ResourceTemplateHandlerFunc {
+		return func(ctx context.Context, request mcp.ReadResourceRequest) ([]interface{}, error) {
+			arguments, err := json.Marshal(request.Params.Arguments)
+			if err != nil {
+				return nil, err
+			}
+			return []interface{}{
+				mcp.TextResourceContents{
+					ResourceContents: mcp.ResourceContents{URI: request.Params.URI},
+					Text:             name + " " + string(arguments),
+				},
+			}, nil
+		}
+	}
+	for _, template := range []string{
+		"test://{+path}",
+		"test://items/{id}",
+		"test://items/{id}/raw",
+		"test://search{?query,page}",
+		"test://invalid/{id",
+	} {
+		server.AddResourceTemplate(
+			mcp.NewResourceTemplate(template, template),
+			templateHandler(template),
+		)
+	}
+
+	assert.Contains(t, errLog.String(), "unclosed expression")
+
+	tests := []struct {
+		uri          string
+		expectedText string
+	}{
+		{
+			uri:          "test://items/42",
+			expectedText: `test://items/{id} {"id":"42"}`,
+		},
+		{
+			uri:          "test://items/42/raw",
+			expectedText: `test://items/{id}/raw {"id":"42"}`,
+		},
+		{
+			uri:          "test://items/42/summary",
+			expectedText: `test://{+path} {"path":"items/42/summary"}`,
+		},
+		{
+			uri:          "test://search?page=2&query=uri%20templates",
+			expectedText: `test://search{?query,page} {"page":"2","query":"uri templates"}`,
+		},
+		{
+			uri:          "test://invalid/7",
+			expectedText: `test://{+path} {"path":"invalid/7"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			response := server.HandleMessage(context.Background(), []byte(fmt.Sprintf(
+				`{"jsonrpc": "2.0", "id": 1, "method": "resources/read", "params": {"uri": %q}}`,
+				tt.uri,
+			)))
+			resp, ok := response.(mcp.JSONRPCResponse)
+			require.True(t, ok)
+			contents := resp.Result.(mcp.ReadResourceResult).Contents
+			require.Len(t, contents, 1)
+			text := contents[0].(mcp.TextResourceContents).Text
+			assert.Equal(t, tt.expectedText, text)
+		})
+	}
+}
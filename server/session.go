@@ -0,0 +1,532 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/shaneholloman/mcp-server-go/mcp"
+)
+
+// defaultSessionID identifies the session used for requests whose context
+// carries no ClientSession, e.g. when HandleMessage is called directly. It has
+// no client to talk to: notifications sent to it are dropped and requests to
+// it fail.
+const defaultSessionID = "default"
+
+// sessionBufferSize is the number of outgoing messages a session queues
+// before further notifications are rejected
+const sessionBufferSize = 100
+
+// SessionState is the stage of the initialization lifecycle a client
+// session is in
+type SessionState int
+
+const (
+	// SessionUninitialized sessions have not sent an initialize request.
+	// Only pings are served.
+	SessionUninitialized SessionState = iota
+	// SessionInitializing sessions have been answered an initialize request
+	// but have not yet sent notifications/initialized
+	SessionInitializing
+	// SessionReady sessions have completed initialization
+	SessionReady
+)
+
+// String returns the name of the state
+func (s SessionState) String() string {
+	switch s {
+	case SessionUninitialized:
+		return "uninitialized"
+	case SessionInitializing:
+		return "initializing"
+	case SessionReady:
+		return "ready"
+	default:
+		return fmt.Sprintf("SessionState(%d)", int(s))
+	}
+}
+
+// ClientSession holds the state of a single client connection: its identity,
+// what was negotiated during initialization and its per-session preferences.
+//
+// Transports create one session per connection, register it with
+// MCPServer.RegisterSession and attach it to the context of every message
+// they dispatch using MCPServer.WithContext. Messages the server sends to the
+// client are queued on the channel returned by Messages, which the transport
+// must drain.
+type ClientSession struct {
+	id        string
+	shared    bool // the default session, which has no lifecycle of its own
+	messages  chan mcp.JSONRPCMessage
+	done      chan struct{}
+	closeOnce sync.Once
+
+	requestID atomic.Int64
+	pendingMu sync.Mutex
+	pending   map[string]chan *clientResponse
+
+	inFlightMu sync.Mutex
+	inFlight   map[string]*inFlightRequest
+
+	mu                 sync.RWMutex // guards the fields below
+	state              SessionState
+	clientInfo         mcp.Implementation
+	clientCapabilities mcp.ClientCapabilities
+	protocolVersion    string
+	logLevel           mcp.LoggingLevel
+	subscriptions      map[string]struct{}
+	roots              []mcp.Root
+	rootsKnown         bool
+	rootsGeneration    uint64 // incremented whenever the roots change
+}
+
+// NewClientSession creates a session with the given ID, which must be unique
+// among the sessions registered with a server
+func NewClientSession(id string) *ClientSession {
+	return &ClientSession{
+		id:            id,
+		messages:      make(chan mcp.JSONRPCMessage, sessionBufferSize),
+		done:          make(chan struct{}),
+		pending:       make(map[string]chan *clientResponse),
+		inFlight:      make(map[string]*inFlightRequest),
+		logLevel:      defaultLoggingLevel,
+		subscriptions: make(map[string]struct{}),
+	}
+}
+
+// ID returns the session ID
+func (c *ClientSession) ID() string {
+	return c.id
+}
+
+// Messages returns the channel of messages the server sends to the client:
+// notifications and server-initiated requests
+func (c *ClientSession) Messages() <-chan mcp.JSONRPCMessage {
+	return c.messages
+}
+
+// State returns the lifecycle state of the session
+func (c *ClientSession) State() SessionState {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.state
+}
+
+// Initialized reports whether the server has accepted the client's
+// initialize request, i.e. the session is initializing or ready
+func (c *ClientSession) Initialized() bool {
+	return c.State() != SessionUninitialized
+}
+
+// ClientInfo returns the name and version the client sent in its initialize
+// request
+func (c *ClientSession) ClientInfo() mcp.Implementation {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.clientInfo
+}
+
+// ClientCapabilities returns the capabilities the client advertised in its
+// initialize request
+func (c *ClientSession) ClientCapabilities() mcp.ClientCapabilities {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.clientCapabilities
+}
+
+// ProtocolVersion returns the protocol version negotiated with the client
+func (c *ClientSession) ProtocolVersion() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.protocolVersion
+}
+
+// LogLevel returns the minimum logging level the client requested via
+// logging/setLevel
+func (c *ClientSession) LogLevel() mcp.LoggingLevel {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.logLevel
+}
+
+// Subscriptions returns the resource URIs and URI templates the client is
+// subscribed to, in sorted order
+func (c *ClientSession) Subscriptions() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	uris := make([]string, 0, len(c.subscriptions))
+	for uri := range c.subscriptions {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+	return uris
+}
+
+// Roots returns the roots last reported by the client, and whether they are
+// known. They are unknown until fetched with MCPServer.ListRoots, and while
+// being refreshed after the client reported a change.
+func (c *ClientSession) Roots() ([]mcp.Root, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if !c.rootsKnown {
+		return nil, false
+	}
+	return append([]mcp.Root{}, c.roots...), true
+}
+
+// initialize records what the client sent in its initialize request and the
+// negotiated protocol version. It reports false, leaving the session
+// unchanged, if the session was already initialized. The default session
+// may be initialized any number of times.
+func (c *ClientSession) initialize(request mcp.InitializeRequest, protocolVersion string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.state != SessionUninitialized && !c.shared {
+		return false
+	}
+	c.state = SessionInitializing
+	c.clientInfo = request.Params.ClientInfo
+	c.clientCapabilities = request.Params.Capabilities
+	c.protocolVersion = protocolVersion
+	return true
+}
+
+// markReady completes initialization once the client has sent
+// notifications/initialized
+func (c *ClientSession) markReady() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.state == SessionInitializing {
+		c.state = SessionReady
+	}
+}
+
+// setLogLevel records the minimum logging level requested by the client
+func (c *ClientSession) setLogLevel(level mcp.LoggingLevel) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.logLevel = level
+}
+
+// subscribe adds uri to the client's resource subscriptions
+func (c *ClientSession) subscribe(uri string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.subscriptions[uri] = struct{}{}
+}
+
+// unsubscribe removes uri from the client's resource subscriptions
+func (c *ClientSession) unsubscribe(uri string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.subscriptions, uri)
+}
+
+// subscribed reports whether the client wants updates for uri, given the
+// resource templates that match it
+func (c *ClientSession) subscribed(uri string, matchingTemplates []string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if _, ok := c.subscriptions[uri]; ok {
+		return true
+	}
+	for _, uriTemplate := range matchingTemplates {
+		if _, ok := c.subscriptions[uriTemplate]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// rootsVersion returns the current roots generation, to be passed to setRoots
+// with the roots fetched afterwards
+func (c *ClientSession) rootsVersion() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.rootsGeneration
+}
+
+// setRoots caches roots fetched from the client, unless they were
+// invalidated since the fetch started
+func (c *ClientSession) setRoots(roots []mcp.Root, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if generation != c.rootsGeneration {
+		return
+	}
+	c.roots = append([]mcp.Root{}, roots...)
+	c.rootsKnown = true
+}
+
+// invalidateRoots discards the cached roots
+func (c *ClientSession) invalidateRoots() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.roots = nil
+	c.rootsKnown = false
+	c.rootsGeneration++
+}
+
+// send queues a message for the client without blocking
+func (c *ClientSession) send(message mcp.JSONRPCMessage) error {
+	// No transport drains the default session, so its messages are dropped
+	// rather than left to fill up its queue
+	if c.shared {
+		return nil
+	}
+
+	select {
+	case <-c.done:
+		return fmt.Errorf("session %s closed", c.id)
+	default:
+	}
+
+	select {
+	case c.messages <- message:
+		return nil
+	default:
+		return fmt.Errorf("session %s: message queue full", c.id)
+	}
+}
+
+// clientResponse is the client's answer to a server-initiated request
+type clientResponse struct {
+	result json.RawMessage
+	err    error
+}
+
+// serverRequest is a JSON-RPC request sent from the server to the client
+type serverRequest struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      int64       `json:"id"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+// request sends a request to the client and waits for its response. It
+// returns early if ctx is done, in which case the client is told that the
+// request was cancelled, or if the session is closed.
+func (c *ClientSession) request(
+	ctx context.Context,
+	method string,
+	params interface{},
+) (json.RawMessage, error) {
+	if c.shared {
+		return nil, fmt.Errorf("session %s has no client to send requests to", c.id)
+	}
+
+	id := c.requestID.Add(1)
+	key := strconv.FormatInt(id, 10)
+
+	responseChan := make(chan *clientResponse, 1)
+	c.pendingMu.Lock()
+	c.pending[key] = responseChan
+	c.pendingMu.Unlock()
+	defer func() {
+		c.pendingMu.Lock()
+		delete(c.pending, key)
+		c.pendingMu.Unlock()
+	}()
+
+	err := c.send(serverRequest{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		ID:      id,
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case response := <-responseChan:
+		return response.result, response.err
+	case <-ctx.Done():
+		_ = c.send(mcp.JSONRPCNotification{
+			JSONRPC: mcp.JSONRPC_VERSION,
+			Notification: mcp.Notification{
+				Method: "notifications/cancelled",
+				Params: mcp.NotificationParams{
+					AdditionalFields: map[string]interface{}{
+						"requestId": id,
+						"reason":    ctx.Err().Error(),
+					},
+				},
+			},
+		})
+		return nil, ctx.Err()
+	case <-c.done:
+		return nil, fmt.Errorf("session %s closed", c.id)
+	}
+}
+
+// deliver hands the client's response to the request waiting for it.
+// Responses to unknown or abandoned requests are dropped.
+func (c *ClientSession) deliver(id interface{}, response *clientResponse) {
+	key, err := json.Marshal(id)
+	if err != nil {
+		return
+	}
+
+	c.pendingMu.Lock()
+	responseChan, ok := c.pending[string(key)]
+	delete(c.pending, string(key))
+	c.pendingMu.Unlock()
+
+	if ok {
+		responseChan <- response
+	}
+}
+
+// close stops the session from accepting further messages
+func (c *ClientSession) close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		c.cancelAllRequests()
+	})
+}
+
+// clientSessionKey is the context key for storing the client session
+type clientSessionKey struct{}
+
+// ClientSessionFromContext returns the client session a request is being
+// handled for, or nil if ctx carries none
+func ClientSessionFromContext(ctx context.Context) *ClientSession {
+	if session, ok := ctx.Value(clientSessionKey{}).(*ClientSession); ok {
+		return session
+	}
+	return nil
+}
+
+// WithContext returns a copy of ctx carrying session, so that requests
+// handled with it, and notifications sent from their handlers, are attributed
+// to that client
+func (s *MCPServer) WithContext(
+	ctx context.Context,
+	session *ClientSession,
+) context.Context {
+	return context.WithValue(ctx, clientSessionKey{}, session)
+}
+
+// RegisterSession makes session known to the server, so that it receives
+// broadcast notifications and can be addressed by ID. Returns an error if a
+// session with the same ID is already registered.
+func (s *MCPServer) RegisterSession(session *ClientSession) error {
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+	if _, ok := s.sessions[session.id]; ok {
+		return fmt.Errorf("session %s already registered", session.id)
+	}
+	s.sessions[session.id] = session
+	return nil
+}
+
+// UnregisterSession discards a session whose client has disconnected.
+// Messages sent to it afterwards are rejected.
+func (s *MCPServer) UnregisterSession(sessionID string) {
+	s.sessionsMu.Lock()
+	session, ok := s.sessions[sessionID]
+	delete(s.sessions, sessionID)
+	s.sessionsMu.Unlock()
+
+	if ok {
+		session.close()
+		s.onSessionClosed(session)
+	}
+}
+
+// sessionFromContext returns the session carried by ctx, falling back to the
+// default session
+func (s *MCPServer) sessionFromContext(ctx context.Context) *ClientSession {
+	if session := ClientSessionFromContext(ctx); session != nil {
+		return session
+	}
+	return s.defaultSession
+}
+
+// getSession returns the registered session with the given ID
+func (s *MCPServer) getSession(sessionID string) (*ClientSession, bool) {
+	s.sessionsMu.RLock()
+	defer s.sessionsMu.RUnlock()
+	session, ok := s.sessions[sessionID]
+	return session, ok
+}
+
+// allSessions returns a snapshot of the registered sessions
+func (s *MCPServer) allSessions() []*ClientSession {
+	s.sessionsMu.RLock()
+	defer s.sessionsMu.RUnlock()
+	sessions := make([]*ClientSession, 0, len(s.sessions))
+	for _, session := range s.sessions {
+		sessions = append(sessions, session)
+	}
+	return sessions
+}
+
+// SendNotificationToClient sends a notification to the client whose request
+// is being handled with ctx. Without a session in ctx the notification goes
+// to the default session, which drops it.
+func (s *MCPServer) SendNotificationToClient(
+	ctx context.Context,
+	method string,
+	params map[string]interface{},
+) error {
+	return s.sendNotification(s.sessionFromContext(ctx), method, params)
+}
+
+// SendNotificationToSession sends a notification to the registered session
+// with the given ID
+func (s *MCPServer) SendNotificationToSession(
+	sessionID string,
+	method string,
+	params map[string]interface{},
+) error {
+	session, ok := s.getSession(sessionID)
+	if !ok {
+		return fmt.Errorf("session %s not found", sessionID)
+	}
+	return s.sendNotification(session, method, params)
+}
+
+// sendNotification queues a notification for session
+func (s *MCPServer) sendNotification(
+	session *ClientSession,
+	method string,
+	params map[string]interface{},
+) error {
+	notification := mcp.JSONRPCNotification{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		Notification: mcp.Notification{
+			Method: method,
+			Params: mcp.NotificationParams{
+				AdditionalFields: params,
+			},
+		},
+	}
+	if err := session.send(notification); err != nil {
+		return err
+	}
+	s.onNotificationSent(session, notification)
+	return nil
+}
+
+// notifyInitializedSessions sends a notification to every registered session
+// that has completed initialization, which excludes sessions still waiting
+// for notifications/initialized
+func (s *MCPServer) notifyInitializedSessions(
+	method string,
+	params map[string]interface{},
+) {
+	for _, session := range s.allSessions() {
+		if session.State() == SessionReady {
+			// A slow or departed client must not affect the others, so
+			// delivery failures are ignored
+			_ = s.sendNotification(session, method, params)
+		}
+	}
+}
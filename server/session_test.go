@@ -0,0 +1,265 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shaneholloman/mcp-server-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestSession registers a session with the server and returns a context
+// carrying it
+func newTestSession(
+	t *testing.T,
+	server *MCPServer,
+	id string,
+) (context.Context, *ClientSession) {
+	t.Helper()
+	session := NewClientSession(id)
+	require.NoError(t, server.RegisterSession(session))
+	t.Cleanup(func() { server.UnregisterSession(id) })
+	return server.WithContext(context.Background(), session), session
+}
+
+// initializeMessage is an initialize request from a client without any
+// optional capabilities
+const initializeMessage = `{
+    "jsonrpc": "2.0",
+    "id": "init",
+    "method": "initialize",
+    "params": {
+        "protocolVersion": "2024-11-05",
+        "capabilities": {},
+        "clientInfo": {"name": "test-client", "version": "1.0.0"}
+    }
+}`
+
+// initializedMessage completes initialization
+const initializedMessage = `{
+    "jsonrpc": "2.0",
+    "method": "notifications/initialized"
+}`
+
+// newReadySession registers a session with the server and takes it through
+// initialization
+func newReadySession(
+	t *testing.T,
+	server *MCPServer,
+	id string,
+) (context.Context, *ClientSession) {
+	t.Helper()
+	ctx, session := newTestSession(t, server, id)
+	response := server.HandleMessage(ctx, []byte(initializeMessage))
+	require.IsType(t, mcp.JSONRPCResponse{}, response)
+	require.Nil(t, server.HandleMessage(ctx, []byte(initializedMessage)))
+	return ctx, session
+}
+
+func TestMCPServer_ClientSessionFromContext(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0")
+	ctx, session := newTestSession(t, server, "session-1")
+
+	var seen *ClientSession
+	server.AddTool(
+		mcp.NewTool("whoami"),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			seen = ClientSessionFromContext(ctx)
+			return mcp.NewToolResultText(seen.ID()), nil
+		},
+	)
+
+	response := server.HandleMessage(ctx, []byte(`{
+        "jsonrpc": "2.0",
+        "id": 1,
+        "method": "initialize",
+        "params": {
+            "protocolVersion": "2024-11-05",
+            "capabilities": {"roots": {"listChanged": true}},
+            "clientInfo": {"name": "test-client", "version": "1.0.0"}
+        }
+    }`))
+	_, ok := response.(mcp.JSONRPCResponse)
+	require.True(t, ok)
+
+	assert.True(t, session.Initialized())
+	assert.Equal(t, "test-client", session.ClientInfo().Name)
+	assert.Equal(t, mcp.LATEST_PROTOCOL_VERSION, session.ProtocolVersion())
+	require.NotNil(t, session.ClientCapabilities().Roots)
+	assert.True(t, session.ClientCapabilities().Roots.ListChanged)
+
+	response = server.HandleMessage(ctx, callToolMessage(2, "whoami"))
+	_, ok = response.(mcp.JSONRPCResponse)
+	require.True(t, ok)
+	assert.Same(t, session, seen)
+
+	// Requests without a session are attributed to the default session
+	server.HandleMessage(context.Background(), callToolMessage(3, "whoami"))
+	assert.Same(t, server.defaultSession, seen)
+
+	assert.Nil(t, ClientSessionFromContext(context.Background()))
+}
+
+func TestMCPServer_SendNotification(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0")
+	ctx1, session1 := newTestSession(t, server, "session-1")
+	_, session2 := newTestSession(t, server, "session-2")
+
+	require.NoError(t, server.SendNotificationToClient(ctx1, "test/one", nil))
+	require.NoError(t, server.SendNotificationToSession(
+		"session-2",
+		"test/two",
+		map[string]interface{}{"n": 2},
+	))
+
+	notifications := drainNotifications(session1)
+	require.Len(t, notifications, 1)
+	assert.Equal(t, "test/one", notifications[0].Method)
+
+	notifications = drainNotifications(session2)
+	require.Len(t, notifications, 1)
+	assert.Equal(t, "test/two", notifications[0].Method)
+	assert.Equal(t, 2, notifications[0].Params.AdditionalFields["n"])
+
+	assert.Error(t, server.SendNotificationToSession("unknown", "test/x", nil))
+
+	server.UnregisterSession("session-1")
+	assert.Error(t, server.SendNotificationToClient(ctx1, "test/one", nil))
+	assert.Error(t, server.SendNotificationToSession("session-1", "test/one", nil))
+}
+
+func TestMCPServer_RegisterSession(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0")
+	newTestSession(t, server, "session-1")
+
+	assert.Error(t, server.RegisterSession(NewClientSession("session-1")))
+
+	full := NewClientSession("full")
+	for i := 0; i < sessionBufferSize; i++ {
+		require.NoError(t, server.sendNotification(full, "test/fill", nil))
+	}
+	assert.Error(t, server.sendNotification(full, "test/fill", nil))
+}
+
+func TestSSEServer_NotificationsReachPostingSession(t *testing.T) {
+	mcpServer := NewMCPServer("test-server", "1.0.0")
+	mcpServer.AddTool(
+		mcp.NewTool("notify"),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			session := ClientSessionFromContext(ctx)
+			err := ServerFromContext(ctx).SendNotificationToClient(
+				ctx,
+				"test/notify",
+				map[string]interface{}{"session": session.ID()},
+			)
+			return mcp.NewToolResultText(session.ID()), err
+		},
+	)
+
+	// Registered as a cleanup so that it runs after the streams are closed
+	testServer := NewTestServer(mcpServer)
+	t.Cleanup(testServer.Close)
+
+	first := connectTestSSE(t, testServer.URL)
+	second := connectTestSSE(t, testServer.URL)
+	first.initialize(t)
+	second.initialize(t)
+
+	// Each session posts in turn; the notification must follow the poster
+	for _, client := range []*testSSEClient{first, second, first} {
+		client.post(t, callToolMessage(1, "notify"))
+
+		// The notification and the response travel on separate paths, so
+		// they may arrive in either order
+		events := client.next(t) + client.next(t)
+		assert.Contains(t, events, `"test/notify"`)
+		assert.Contains(t, events, `"result"`)
+		assert.Equal(t, 2, strings.Count(events, client.sessionID))
+	}
+}
+
+// testSSEClient is a minimal client for an SSE test server
+type testSSEClient struct {
+	messageURL string
+	sessionID  string
+	events     chan string
+}
+
+// connectTestSSE opens an SSE stream and waits for its message endpoint
+func connectTestSSE(t *testing.T, baseURL string) *testSSEClient {
+	t.Helper()
+	resp, err := http.Get(baseURL + "/sse")
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+
+	client := &testSSEClient{events: make(chan string, 100)}
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+				client.events <- data
+			}
+		}
+		close(client.events)
+	}()
+
+	client.messageURL = strings.TrimSpace(client.next(t))
+	client.sessionID = strings.Split(client.messageURL, "sessionId=")[1]
+	return client
+}
+
+// initialize takes the session through initialization
+func (c *testSSEClient) initialize(t *testing.T) {
+	t.Helper()
+	c.post(t, []byte(initializeMessage))
+	assert.Contains(t, c.next(t), `"result"`)
+	c.post(t, []byte(initializedMessage))
+}
+
+// post sends a message to the session's message endpoint
+func (c *testSSEClient) post(t *testing.T, message []byte) {
+	t.Helper()
+	resp, err := http.Post(c.messageURL, "application/json", bytes.NewReader(message))
+	require.NoError(t, err)
+	resp.Body.Close()
+}
+
+// next returns the data of the next event on the stream
+func (c *testSSEClient) next(t *testing.T) string {
+	t.Helper()
+	select {
+	case data, ok := <-c.events:
+		require.True(t, ok, "SSE stream closed")
+		return data
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for SSE event")
+		return ""
+	}
+}
+
+func TestMCPServer_DefaultSessionDropsMessages(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0")
+
+	// Nothing drains the default session, so it must never fill up
+	for i := 0; i < 2*sessionBufferSize; i++ {
+		require.NoError(t, server.SendNotificationToClient(
+			context.Background(),
+			"notifications/message",
+			map[string]interface{}{"data": i},
+		))
+	}
+	assert.Empty(t, drainNotifications(server.defaultSession))
+
+	// Requests to it fail at once instead of waiting for a response
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err := server.defaultSession.request(ctx, "ping", nil)
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"sync"
 
 	"github.com/google/uuid"
@@ -23,9 +25,42 @@ type SSEServer struct {
 
 // sseSession represents an active SSE connection.
 type sseSession struct {
-	writer  http.ResponseWriter
-	flusher http.Flusher
-	done    chan struct{}
+	client    *ClientSession
+	writer    http.ResponseWriter
+	flusher   http.Flusher
+	mu        sync.Mutex // serializes writes to the event stream
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// writeEvent writes a single event to the session's stream. Writes from
+// concurrent request handlers and the server's outgoing messages are serialized,
+// and writes after the stream was closed are rejected.
+func (s *sseSession) writeEvent(event string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	select {
+	case <-s.done:
+		return fmt.Errorf("session closed")
+	default:
+	}
+
+	if _, err := fmt.Fprintf(s.writer, "event: %s\ndata: %s\n\n", event, data); err != nil {
+		return err
+	}
+	s.flusher.Flush()
+	return nil
+}
+
+// close marks the session as closed. It is safe to call more than once.
+func (s *sseSession) close() {
+	s.closeOnce.Do(func() {
+		// Wait for an in-progress write before the stream goes away
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		close(s.done)
+	})
 }
 
 // NewSSEServer creates a new SSE server instance with the given MCP server and base URL.
@@ -80,7 +115,7 @@ func (s *SSEServer) Shutdown(ctx context.Context) error {
 	if s.srv != nil {
 		s.sessions.Range(func(key, value interface{}) bool {
 			if session, ok := value.(*sseSession); ok {
-				close(session.done)
+				session.close()
 			}
 			s.sessions.Delete(key)
 			return true
@@ -112,48 +147,55 @@ func (s *SSEServer) handleSSE(w http.ResponseWriter, r *http.Request) {
 
 	sessionID := uuid.New().String()
 	session := &sseSession{
+		client:  NewClientSession(sessionID),
 		writer:  w,
 		flusher: flusher,
 		done:    make(chan struct{}),
 	}
 
+	if err := s.server.RegisterSession(session.client); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer s.server.UnregisterSession(sessionID)
+
 	s.sessions.Store(sessionID, session)
 	defer s.sessions.Delete(sessionID)
 
-	// Start notification handler for this session
-	go func() {
-		for {
-			select {
-			case serverNotification := <-s.server.notifications:
-				// Only forward notifications meant for this session
-				if serverNotification.Context.SessionID == sessionID {
-					s.SendEventToSession(
-						sessionID,
-						serverNotification.Notification,
-					)
-				}
-			case <-session.done:
-				return
-			case <-r.Context().Done():
-				return
-			}
-		}
-	}()
-
 	messageEndpoint := fmt.Sprintf(
 		"%s/message?sessionId=%s",
 		s.baseURL,
 		sessionID,
 	)
+	session.mu.Lock()
 	fmt.Fprintf(w, "event: endpoint\ndata: %s\r\n\r\n", messageEndpoint)
 	flusher.Flush()
+	session.mu.Unlock()
 
-	<-r.Context().Done()
-	close(session.done)
+	defer session.close()
+
+	// Forward the messages the server sends to this client
+	for {
+		select {
+		case message := <-session.client.Messages():
+			eventData, err := json.Marshal(message)
+			if err != nil {
+				continue
+			}
+			if err := session.writeEvent("message", eventData); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		case <-session.done:
+			return
+		}
+	}
 }
 
 // handleMessage processes incoming JSON-RPC messages from clients and sends responses
-// back through both the SSE connection and HTTP response.
+// back through both the SSE connection and HTTP response. A batch posted at
+// once is answered with a single array of responses.
 func (s *SSEServer) handleMessage(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		s.writeJSONRPCError(w, nil, mcp.INVALID_REQUEST, "Method not allowed")
@@ -166,12 +208,6 @@ func (s *SSEServer) handleMessage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Set the client context in the server before handling the message
-	ctx := s.server.WithContext(r.Context(), NotificationContext{
-		ClientID:  sessionID,
-		SessionID: sessionID,
-	})
-
 	sessionI, ok := s.sessions.Load(sessionID)
 	if !ok {
 		s.writeJSONRPCError(w, nil, mcp.INVALID_PARAMS, "Invalid session ID")
@@ -179,6 +215,9 @@ func (s *SSEServer) handleMessage(w http.ResponseWriter, r *http.Request) {
 	}
 	session := sessionI.(*sseSession)
 
+	// Attribute the message to the client session it was posted for
+	ctx := s.server.WithContext(r.Context(), session.client)
+
 	// Parse message as raw JSON
 	var rawMessage json.RawMessage
 	if err := json.NewDecoder(r.Body).Decode(&rawMessage); err != nil {
@@ -192,12 +231,18 @@ func (s *SSEServer) handleMessage(w http.ResponseWriter, r *http.Request) {
 	// Only send response if there is one (not for notifications)
 	if response != nil {
 		eventData, _ := json.Marshal(response)
-		fmt.Fprintf(session.writer, "event: message\ndata: %s\n\n", eventData)
-		session.flusher.Flush()
+		// The HTTP response below still carries the result if the
+		// stream has gone away in the meantime
+		_ = session.writeEvent("message", eventData)
 
 		// Send HTTP response
 		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusAccepted)
+		if retryAfter, ok := rateLimited(response); ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter))))
+			w.WriteHeader(http.StatusTooManyRequests)
+		} else {
+			w.WriteHeader(http.StatusAccepted)
+		}
 		json.NewEncoder(w).Encode(response)
 	} else {
 		// For notifications, just send 202 Accepted with no body
@@ -205,6 +250,30 @@ func (s *SSEServer) handleMessage(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// rateLimited reports whether response rejects a request for exceeding a
+// rate limit, or every request of a batch, and how many seconds the client
+// should wait before retrying
+func rateLimited(response mcp.JSONRPCMessage) (float64, bool) {
+	if batch, ok := response.([]mcp.JSONRPCMessage); ok {
+		longest := 0.0
+		for _, response := range batch {
+			retryAfter, ok := rateLimited(response)
+			if !ok {
+				return 0, false
+			}
+			longest = math.Max(longest, retryAfter)
+		}
+		return longest, len(batch) > 0
+	}
+	errorResponse, ok := response.(mcp.JSONRPCError)
+	if !ok || errorResponse.Error.Code != mcp.RATE_LIMITED {
+		return 0, false
+	}
+	data, _ := errorResponse.Error.Data.(map[string]interface{})
+	retryAfter, _ := data["retryAfter"].(float64)
+	return retryAfter, true
+}
+
 // writeJSONRPCError writes a JSON-RPC error response with the given error details.
 func (s *SSEServer) writeJSONRPCError(
 	w http.ResponseWriter,
@@ -235,12 +304,5 @@ func (s *SSEServer) SendEventToSession(
 		return err
 	}
 
-	select {
-	case <-session.done:
-		return fmt.Errorf("session closed")
-	default:
-		fmt.Fprintf(session.writer, "event: message\ndata: %s\n\n", eventData)
-		session.flusher.Flush()
-		return nil
-	}
+	return session.writeEvent("message", eventData)
 }
@@ -9,6 +9,7 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 
 	"github.com/shaneholloman/mcp-server-go/mcp"
@@ -20,6 +21,7 @@ import (
 type StdioServer struct {
 	server    *MCPServer
 	errLogger *log.Logger
+	writeMu   sync.Mutex // serializes messages written to the output
 }
 
 // NewStdioServer creates a new stdio server wrapper around an MCPServer.
@@ -49,11 +51,23 @@ func (s *StdioServer) Listen(
 	stdin io.Reader,
 	stdout io.Writer,
 ) error {
-	// Set a static client context since stdio only has one client
-	ctx = s.server.WithContext(ctx, NotificationContext{
-		ClientID:  "stdio",
-		SessionID: "stdio",
-	})
+	// Stdio only has one client, served by a single session
+	session := NewClientSession("stdio")
+	if err := s.server.RegisterSession(session); err != nil {
+		return err
+	}
+	defer s.server.UnregisterSession(session.ID())
+	ctx = s.server.WithContext(ctx, session)
+
+	// Requests still being handled are waited for before the session is
+	// unregistered, so their responses are not lost. They are cancelled
+	// first if Listen fails before reaching the end of the input.
+	var inFlight sync.WaitGroup
+	defer inFlight.Wait()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// failed receives the first error handling a request concurrently
+	failed := make(chan error, 1)
 
 	reader := bufio.NewReader(stdin)
 
@@ -61,22 +75,17 @@ func (s *StdioServer) Listen(
 	go func() {
 		for {
 			select {
-			case serverNotification := <-s.server.notifications:
-				// Only handle notifications for stdio client
-				if serverNotification.Context.ClientID == "stdio" {
-					err := s.writeResponse(
-						serverNotification.Notification,
-						stdout,
+			case message := <-session.Messages():
+				if err := s.writeResponse(message, stdout); err != nil {
+					s.errLogger.Printf(
+						"Error writing notification: %v",
+						err,
 					)
-					if err != nil {
-						s.errLogger.Printf(
-							"Error writing notification: %v",
-							err,
-						)
-					}
 				}
 			case <-ctx.Done():
 				return
+			case <-session.done:
+				return
 			}
 		}
 	}()
@@ -104,24 +113,124 @@ func (s *StdioServer) Listen(
 				return ctx.Err()
 			case err := <-errChan:
 				if err == io.EOF {
+					inFlight.Wait()
+					select {
+					case err := <-failed:
+						return s.handlingFailed(err)
+					default:
+					}
+					s.flushNotifications(session, stdout)
 					return nil
 				}
 				s.errLogger.Printf("Error reading input: %v", err)
 				return err
+			case err := <-failed:
+				return s.handlingFailed(err)
 			case line := <-readChan:
-				if err := s.processMessage(ctx, line, stdout); err != nil {
-					if err == io.EOF {
-						return nil
-					}
-					s.errLogger.Printf("Error handling message: %v", err)
-					return err
+				if err := s.dispatch(ctx, &inFlight, failed, line, stdout); err != nil {
+					return s.handlingFailed(err)
 				}
 			}
 		}
 	}
 }
 
-// processMessage handles a single JSON-RPC message and writes the response.
+// handlingFailed logs an error handling a message, which ends Listen, and
+// returns what Listen returns
+func (s *StdioServer) handlingFailed(err error) error {
+	if err == io.EOF {
+		return nil
+	}
+	s.errLogger.Printf("Error handling message: %v", err)
+	return err
+}
+
+// dispatch handles a message read from the input. Requests run in their own
+// goroutine, tracked by inFlight, so a slow request does not hold up the ones
+// that follow it; dispatch still waits until the request can be cancelled.
+// Notifications, responses and initialize requests are handled before the
+// next message is read, since the messages after them may depend on them.
+// The error of a message handled before dispatch returns is returned, while
+// the first error of a request handled concurrently is sent to failed.
+func (s *StdioServer) dispatch(
+	ctx context.Context,
+	inFlight *sync.WaitGroup,
+	failed chan<- error,
+	line string,
+	writer io.Writer,
+) error {
+	if !runsConcurrently(line) {
+		return s.processMessage(ctx, line, writer)
+	}
+
+	started := make(chan struct{})
+	var once sync.Once
+	markStarted := func() { once.Do(func() { close(started) }) }
+
+	inFlight.Add(1)
+	go func() {
+		defer inFlight.Done()
+		defer markStarted()
+		err := s.processMessage(withRequestStarted(ctx, markStarted), line, writer)
+		if err != nil {
+			select {
+			case failed <- err:
+			default:
+			}
+		}
+	}()
+	<-started
+	return nil
+}
+
+// runsConcurrently reports whether line holds a request, or a batch of
+// requests only, that may be handled concurrently with later messages
+func runsConcurrently(line string) bool {
+	message := json.RawMessage(line)
+	if !isBatch(message) {
+		return isConcurrentRequest(message)
+	}
+	var batch []json.RawMessage
+	if err := json.Unmarshal(message, &batch); err != nil || len(batch) == 0 {
+		return false
+	}
+	for _, element := range batch {
+		if !isConcurrentRequest(element) {
+			return false
+		}
+	}
+	return true
+}
+
+// isConcurrentRequest reports whether message is a request other than
+// initialize
+func isConcurrentRequest(message json.RawMessage) bool {
+	var request struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+	}
+	if err := json.Unmarshal(message, &request); err != nil {
+		return false
+	}
+	return len(request.ID) > 0 && string(request.ID) != "null" &&
+		request.Method != "" && request.Method != "initialize"
+}
+
+// flushNotifications writes the notifications still queued for session
+func (s *StdioServer) flushNotifications(session *ClientSession, writer io.Writer) {
+	for {
+		select {
+		case message := <-session.Messages():
+			if err := s.writeResponse(message, writer); err != nil {
+				s.errLogger.Printf("Error writing notification: %v", err)
+			}
+		default:
+			return
+		}
+	}
+}
+
+// processMessage handles a single JSON-RPC message or batch and writes the response.
 // It parses the message, processes it through the wrapped MCPServer, and writes any response.
 // Returns an error if there are issues with message processing or response writing.
 func (s *StdioServer) processMessage(
@@ -150,6 +259,7 @@ func (s *StdioServer) processMessage(
 }
 
 // writeResponse marshals and writes a JSON-RPC response message followed by a newline.
+// It is safe to call from multiple goroutines.
 // Returns an error if marshaling or writing fails.
 func (s *StdioServer) writeResponse(
 	response mcp.JSONRPCMessage,
@@ -160,6 +270,9 @@ func (s *StdioServer) writeResponse(
 		return err
 	}
 
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
 	// Write response followed by newline
 	if _, err := fmt.Fprintf(writer, "%s\n", responseBytes); err != nil {
 		return err
@@ -2,11 +2,17 @@ package server
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"log"
 	"testing"
+	"time"
+
+	"github.com/shaneholloman/mcp-server-go/mcp"
 )
 
 func TestStdioServer(t *testing.T) {
@@ -111,3 +117,134 @@ func TestStdioServer(t *testing.T) {
 		}
 	})
 }
+
+func TestStdioServer_PipelinedInput(t *testing.T) {
+	mcpServer := NewMCPServer("test", "1.0.0")
+	mcpServer.AddTool(
+		mcp.NewTool("slow"),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			time.Sleep(20 * time.Millisecond)
+			return mcp.NewToolResultText("done"), nil
+		},
+	)
+	mcpServer.AddTool(
+		mcp.NewTool("blocking"),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(5 * time.Second):
+				return mcp.NewToolResultText("not cancelled"), nil
+			}
+		},
+	)
+	stdioServer := NewStdioServer(mcpServer)
+	stdioServer.SetErrorLogger(log.New(io.Discard, "", 0))
+
+	// Every message is written at once and stdin is then closed, so the
+	// server reaches EOF while the requests are still being handled
+	var input bytes.Buffer
+	for _, message := range []string{
+		initializeMessage,
+		initializedMessage,
+		`{"jsonrpc": "2.0", "id": 2, "method": "tools/call", "params": {"name": "slow"}}`,
+		`{"jsonrpc": "2.0", "id": 3, "method": "tools/call", "params": {"name": "blocking"}}`,
+		`{"jsonrpc": "2.0", "method": "notifications/cancelled", "params": {"requestId": 3}}`,
+	} {
+		if err := json.Compact(&input, []byte(message)); err != nil {
+			t.Fatal(err)
+		}
+		input.WriteByte('\n')
+	}
+
+	var output bytes.Buffer
+	start := time.Now()
+	if err := stdioServer.Listen(context.Background(), &input, &output); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("cancelled request was not cancelled, Listen took %v", elapsed)
+	}
+
+	responses := map[string]map[string]interface{}{}
+	scanner := bufio.NewScanner(&output)
+	for scanner.Scan() {
+		var response map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &response); err != nil {
+			t.Fatalf("failed to unmarshal response: %v", err)
+		}
+		if id, ok := response["id"]; ok {
+			responses[fmt.Sprint(id)] = response
+		}
+	}
+
+	if len(responses) != 2 {
+		t.Fatalf("expected responses to requests init and 2, got %v", responses)
+	}
+	for _, id := range []string{"init", "2"} {
+		response, ok := responses[id]
+		if !ok {
+			t.Errorf("missing response to request %v", id)
+			continue
+		}
+		if response["error"] != nil {
+			t.Errorf("unexpected error in response to request %v: %v", id, response["error"])
+		}
+	}
+}
+
+// failingWriter is an output whose writes all fail
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, io.ErrClosedPipe
+}
+
+func TestStdioServer_WriteError(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+	}{
+		{
+			name:    "Initialize request",
+			message: initializeMessage,
+		},
+		{
+			name:    "Concurrent request",
+			message: `{"jsonrpc": "2.0", "id": 1, "method": "ping"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdioServer := NewStdioServer(NewMCPServer("test", "1.0.0"))
+			stdioServer.SetErrorLogger(log.New(io.Discard, "", 0))
+
+			// Stdin stays open, so only the failed write can end Listen
+			stdinReader, stdinWriter := io.Pipe()
+			defer stdinWriter.Close()
+			done := make(chan error, 1)
+			go func() {
+				done <- stdioServer.Listen(context.Background(), stdinReader, failingWriter{})
+			}()
+
+			var message bytes.Buffer
+			if err := json.Compact(&message, []byte(tt.message)); err != nil {
+				t.Fatal(err)
+			}
+			message.WriteByte('\n')
+			if _, err := stdinWriter.Write(message.Bytes()); err != nil {
+				t.Fatal(err)
+			}
+
+			select {
+			case err := <-done:
+				if !errors.Is(err, io.ErrClosedPipe) {
+					t.Errorf("expected a write error, got %v", err)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("Listen did not return after failing to write")
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/shaneholloman/mcp-server-go/mcp"
+)
+
+// subscribable reports whether uri names a registered resource, a registered
+// resource template, or a URI matched by a registered resource template
+func (s *MCPServer) subscribable(uri string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if _, ok := s.resources[uri]; ok {
+		return true
+	}
+	if _, ok := s.resourceTemplates[uri]; ok {
+		return true
+	}
+	for _, entry := range s.resourceTemplates {
+		if entry.uriTemplate == nil {
+			continue
+		}
+		if _, ok := entry.uriTemplate.Match(uri); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *MCPServer) handleSubscribe(
+	ctx context.Context,
+	id interface{},
+	request mcp.SubscribeRequest,
+) mcp.JSONRPCMessage {
+	uri := request.Params.URI
+	if !s.subscribable(uri) {
+		return mcp.NewJSONRPCError(
+			id,
+			mcp.RESOURCE_NOT_FOUND,
+			fmt.Sprintf("Resource not found: %s", uri),
+			map[string]interface{}{"uri": uri},
+		)
+	}
+
+	ClientSessionFromContext(ctx).subscribe(uri)
+	return createResponse(id, mcp.EmptyResult{})
+}
+
+func (s *MCPServer) handleUnsubscribe(
+	ctx context.Context,
+	id interface{},
+	request mcp.UnsubscribeRequest,
+) mcp.JSONRPCMessage {
+	ClientSessionFromContext(ctx).unsubscribe(request.Params.URI)
+	return createResponse(id, mcp.EmptyResult{})
+}
+
+// NotifyResourceUpdated sends a notifications/resources/updated notification
+// for uri to every session subscribed to it, either directly or through a
+// subscription to a registered resource template that matches uri.
+func (s *MCPServer) NotifyResourceUpdated(uri string) error {
+	var matchingTemplates []string
+	s.mu.RLock()
+	for uriTemplate, entry := range s.resourceTemplates {
+		if entry.uriTemplate == nil {
+			continue
+		}
+		if _, ok := entry.uriTemplate.Match(uri); ok {
+			matchingTemplates = append(matchingTemplates, uriTemplate)
+		}
+	}
+	s.mu.RUnlock()
+
+	var errs []error
+	for _, session := range s.allSessions() {
+		if !session.subscribed(uri, matchingTemplates) {
+			continue
+		}
+		err := s.sendNotification(
+			session,
+			"notifications/resources/updated",
+			map[string]interface{}{"uri": uri},
+		)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
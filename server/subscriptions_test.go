@@ -0,0 +1,183 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shaneholloman/mcp-server-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createSubscriptionTestServer() *MCPServer {
+	server := NewMCPServer("test-server", "1.0.0",
+		WithResourceCapabilities(true, true),
+	)
+	handler := func(ctx context.Context, request mcp.ReadResourceRequest) ([]interface{}, error) {
+		return []interface{}{}, nil
+	}
+	server.AddResource(mcp.NewResource("test://static", "Static"), handler)
+	server.AddResourceTemplate(
+		mcp.NewResourceTemplate("test://items/{id}", "Item"),
+		handler,
+	)
+	return server
+}
+
+func subscribeMessage(method, uri string) []byte {
+	return []byte(fmt.Sprintf(
+		`{"jsonrpc": "2.0", "id": 1, "method": %q, "params": {"uri": %q}}`,
+		method,
+		uri,
+	))
+}
+
+func TestMCPServer_Subscriptions(t *testing.T) {
+	server := createSubscriptionTestServer()
+
+	contexts := map[string]context.Context{}
+	sessions := map[string]*ClientSession{}
+	for _, id := range []string{"session-1", "session-2", "session-3"} {
+		contexts[id], sessions[id] = newReadySession(t, server, id)
+	}
+
+	subscribe := func(sessionID, uri string) mcp.JSONRPCMessage {
+		return server.HandleMessage(
+			contexts[sessionID],
+			subscribeMessage("resources/subscribe", uri),
+		)
+	}
+
+	_, ok := subscribe("session-1", "test://static").(mcp.JSONRPCResponse)
+	require.True(t, ok)
+	_, ok = subscribe("session-2", "test://items/42").(mcp.JSONRPCResponse)
+	require.True(t, ok)
+	_, ok = subscribe("session-3", "test://items/{id}").(mcp.JSONRPCResponse)
+	require.True(t, ok)
+
+	errorResponse, ok := subscribe("session-1", "test://unknown").(mcp.JSONRPCError)
+	require.True(t, ok)
+	assert.Equal(t, mcp.RESOURCE_NOT_FOUND, errorResponse.Error.Code)
+	assert.Equal(t, map[string]interface{}{"uri": "test://unknown"}, errorResponse.Error.Data)
+
+	recipients := func(uri string) []string {
+		require.NoError(t, server.NotifyResourceUpdated(uri))
+		var ids []string
+		for id, session := range sessions {
+			for _, notification := range drainNotifications(session) {
+				assert.Equal(
+					t,
+					"notifications/resources/updated",
+					notification.Method,
+				)
+				assert.Equal(
+					t,
+					uri,
+					notification.Params.AdditionalFields["uri"],
+				)
+				ids = append(ids, id)
+			}
+		}
+		return ids
+	}
+
+	assert.Equal(t, []string{"session-1"}, recipients("test://static"))
+	assert.ElementsMatch(
+		t,
+		[]string{"session-2", "session-3"},
+		recipients("test://items/42"),
+	)
+	assert.Equal(t, []string{"session-3"}, recipients("test://items/7"))
+
+	response := server.HandleMessage(
+		contexts["session-2"],
+		subscribeMessage("resources/unsubscribe", "test://items/42"),
+	)
+	_, ok = response.(mcp.JSONRPCResponse)
+	require.True(t, ok)
+	assert.Equal(t, []string{"session-3"}, recipients("test://items/42"))
+	assert.Equal(t, []string{"test://items/{id}"}, sessions["session-3"].Subscriptions())
+
+	server.UnregisterSession("session-3")
+	assert.Empty(t, recipients("test://items/42"))
+}
+
+func TestMCPServer_SubscriptionsNotSupported(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0",
+		WithResourceCapabilities(false, true),
+	)
+
+	for _, method := range []string{"resources/subscribe", "resources/unsubscribe"} {
+		response := server.HandleMessage(
+			context.Background(),
+			subscribeMessage(method, "test://static"),
+		)
+		errorResponse, ok := response.(mcp.JSONRPCError)
+		require.True(t, ok)
+		assert.Equal(t, mcp.METHOD_NOT_FOUND, errorResponse.Error.Code)
+	}
+}
+
+func TestSSEServer_ResourceSubscriptions(t *testing.T) {
+	mcpServer := createSubscriptionTestServer()
+	testServer := NewTestServer(mcpServer)
+	defer testServer.Close()
+
+	sseResp, err := http.Get(fmt.Sprintf("%s/sse", testServer.URL))
+	require.NoError(t, err)
+
+	events := make(chan string, 10)
+	go func() {
+		scanner := bufio.NewScanner(sseResp.Body)
+		for scanner.Scan() {
+			if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+				events <- data
+			}
+		}
+		close(events)
+	}()
+
+	messageURL := strings.TrimSpace(<-events)
+	sessionID := strings.Split(messageURL, "sessionId=")[1]
+
+	resp, err := http.Post(
+		messageURL,
+		"application/json",
+		strings.NewReader(initializeMessage),
+	)
+	require.NoError(t, err)
+	resp.Body.Close()
+	<-events // initialize response
+
+	resp, err = http.Post(
+		messageURL,
+		"application/json",
+		bytes.NewReader(subscribeMessage("resources/subscribe", "test://static")),
+	)
+	require.NoError(t, err)
+	resp.Body.Close()
+	<-events // subscribe response
+
+	require.NoError(t, mcpServer.NotifyResourceUpdated("test://static"))
+
+	select {
+	case data := <-events:
+		assert.Contains(t, data, "notifications/resources/updated")
+		assert.Contains(t, data, "test://static")
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for resource updated notification")
+	}
+
+	// Closing the SSE stream discards the session and its subscriptions
+	sseResp.Body.Close()
+	assert.Eventually(t, func() bool {
+		_, ok := mcpServer.getSession(sessionID)
+		return !ok
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,397 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shaneholloman/mcp-server-go/mcp"
+)
+
+// TypedToolHandlerFunc handles a tool call with arguments decoded into Args
+type TypedToolHandlerFunc[Args, Out any] func(ctx context.Context, args Args) (Out, error)
+
+// AddTypedTool registers tool with an input schema derived from the struct
+// type Args, replacing any schema it was given, and with handler receiving
+// the arguments decoded into Args. Like AddTool, it takes registration
+// options such as ToolTimeout or UseToolMiddleware. See NewTypedTool.
+func AddTypedTool[Args, Out any](
+	s *MCPServer,
+	tool mcp.Tool,
+	handler TypedToolHandlerFunc[Args, Out],
+	opts ...ToolRegistrationOption,
+) {
+	typed := newTypedTool(tool, handler)
+	s.AddTool(typed.Tool, typed.Handler, append(typed.Options, opts...)...)
+}
+
+// NewTypedTool builds a tool whose input schema is reflected from the struct
+// type Args, for use with AddTool or SetTools.
+//
+// Properties are named after the fields' json tags and are required unless
+// tagged omitempty, declared as pointers or given a default. A jsonschema tag refines each
+// property with comma separated key=value pairs: description, title, enum
+// (repeated once per value), default, format, pattern, minimum, maximum,
+// multipleOf, minLength, maxLength, minItems and maxItems, along with the
+// flags required and optional. Commas inside values are escaped as \,.
+//
+// Arguments are validated against the schema and decoded into Args before
+// handler runs, and invalid arguments are reported to the client in a
+// CallToolResult with IsError set. The returned value becomes the result:
+// a *mcp.CallToolResult is used as is, strings and content become a single
+// content item, and anything else is encoded as JSON text. Errors returned by
+// handler are also reported in the result so the model can see them, except
+// for *mcp.Error which is sent as a JSON-RPC error.
+//
+// NewTypedTool panics if Args is not a struct or its tags are invalid.
+func NewTypedTool[Args, Out any](
+	name string,
+	handler TypedToolHandlerFunc[Args, Out],
+	opts ...mcp.ToolOption,
+) ServerTool {
+	return newTypedTool(mcp.NewTool(name, opts...), handler)
+}
+
+// newTypedTool gives tool the input schema reflected from Args and wraps
+// handler to decode the arguments into Args
+func newTypedTool[Args, Out any](
+	tool mcp.Tool,
+	handler TypedToolHandlerFunc[Args, Out],
+) ServerTool {
+	name := tool.Name
+	schema, err := reflectInputSchema(reflect.TypeOf((*Args)(nil)).Elem())
+	if err != nil {
+		panic(fmt.Sprintf("tool %s: %v", name, err))
+	}
+	tool.InputSchema = schema
+
+	return ServerTool{
+		Tool: tool,
+		Handler: func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			var args Args
+			if err := decodeArguments(request.Params.Arguments, &args); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf(
+					"Invalid arguments for tool %s: %v",
+					name,
+					err,
+				)), nil
+			}
+			out, err := handler(ctx, args)
+			if err != nil {
+				var rpcErr *mcp.Error
+				if errors.As(err, &rpcErr) {
+					return nil, err
+				}
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			return toolResult(out)
+		},
+		Options: []ToolRegistrationOption{ValidateToolArguments(true)},
+	}
+}
+
+// decodeArguments decodes the arguments of a tool call into args
+func decodeArguments(arguments map[string]interface{}, args interface{}) error {
+	if arguments == nil {
+		arguments = map[string]interface{}{}
+	}
+	data, err := json.Marshal(arguments)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, args); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			return fmt.Errorf(
+				"%s: expected %s, got %s",
+				typeErr.Field,
+				typeErr.Type,
+				typeErr.Value,
+			)
+		}
+		return err
+	}
+	return nil
+}
+
+// toolResult converts the value returned by a typed tool into a result
+func toolResult(out interface{}) (*mcp.CallToolResult, error) {
+	switch out := out.(type) {
+	case *mcp.CallToolResult:
+		if out == nil {
+			return &mcp.CallToolResult{Content: []interface{}{}}, nil
+		}
+		return out, nil
+	case mcp.CallToolResult:
+		return &out, nil
+	case string:
+		return mcp.NewToolResultText(out), nil
+	case mcp.TextContent, mcp.ImageContent, mcp.EmbeddedResource:
+		return &mcp.CallToolResult{Content: []interface{}{out}}, nil
+	}
+	data, err := json.Marshal(out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode tool result: %w", err)
+	}
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// reflectInputSchema derives a tool input schema from a struct type
+func reflectInputSchema(t reflect.Type) (mcp.ToolInputSchema, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return mcp.ToolInputSchema{}, fmt.Errorf(
+			"arguments must be a struct, got %s",
+			t,
+		)
+	}
+	properties, required, err := reflectProperties(t, map[reflect.Type]bool{t: true})
+	if err != nil {
+		return mcp.ToolInputSchema{}, err
+	}
+	return mcp.ToolInputSchema{
+		Type:       "object",
+		Properties: properties,
+		Required:   required,
+	}, nil
+}
+
+// reflectProperties returns the properties of the struct type t and the
+// names of those that are required. seen holds the struct types being
+// reflected, so recursive types end in a plain object schema.
+func reflectProperties(
+	t reflect.Type,
+	seen map[reflect.Type]bool,
+) (map[string]interface{}, []string, error) {
+	properties := map[string]interface{}{}
+	var required []string
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, omitEmpty, ok := jsonFieldName(field)
+		if !ok {
+			continue
+		}
+
+		// Embedded structs without a name are flattened, as encoding/json does
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && field.Tag.Get("json") == "" && fieldType.Kind() == reflect.Struct {
+			embedded, embeddedRequired, err := reflectProperties(fieldType, seen)
+			if err != nil {
+				return nil, nil, err
+			}
+			for name, schema := range embedded {
+				properties[name] = schema
+			}
+			required = append(required, embeddedRequired...)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		schema, err := reflectSchema(field.Type, seen)
+		if err != nil {
+			return nil, nil, fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		isRequired := !omitEmpty && field.Type.Kind() != reflect.Pointer
+		if tag, ok := field.Tag.Lookup("jsonschema"); ok {
+			if err := applySchemaTag(schema, field.Type, tag, &isRequired); err != nil {
+				return nil, nil, fmt.Errorf("field %s: %w", field.Name, err)
+			}
+		}
+		properties[name] = schema
+		if isRequired {
+			required = append(required, name)
+		}
+	}
+	return properties, required, nil
+}
+
+// jsonFieldName returns the name a struct field is encoded with and whether
+// it is tagged omitempty. Fields encoding/json ignores are not ok.
+func jsonFieldName(field reflect.StructField) (string, bool, bool) {
+	if !field.IsExported() && !field.Anonymous {
+		return "", false, false
+	}
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, false
+	}
+	name, options, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
+	}
+	omitEmpty := false
+	for _, option := range strings.Split(options, ",") {
+		if option == "omitempty" || option == "omitzero" {
+			omitEmpty = true
+		}
+	}
+	return name, omitEmpty, true
+}
+
+// reflectSchema returns the JSON Schema of values of type t
+func reflectSchema(t reflect.Type, seen map[reflect.Type]bool) (map[string]interface{}, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}, nil
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}, nil
+	case reflect.Interface:
+		return map[string]interface{}{}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// encoding/json encodes byte slices as base64 strings
+			return map[string]interface{}{"type": "string"}, nil
+		}
+		items, err := reflectSchema(t.Elem(), seen)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "array", "items": items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("unsupported map key type %s", t.Key())
+		}
+		values, err := reflectSchema(t.Elem(), seen)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": values,
+		}, nil
+	case reflect.Struct:
+		if seen[t] {
+			return map[string]interface{}{"type": "object"}, nil
+		}
+		seen[t] = true
+		defer delete(seen, t)
+		properties, required, err := reflectProperties(t, seen)
+		if err != nil {
+			return nil, err
+		}
+		schema := map[string]interface{}{
+			"type":       "object",
+			"properties": properties,
+		}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema, nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", t)
+}
+
+// applySchemaTag refines schema with the key=value pairs of a jsonschema tag
+// on a field of type t
+func applySchemaTag(
+	schema map[string]interface{},
+	t reflect.Type,
+	tag string,
+	required *bool,
+) error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	var enum []interface{}
+	explicit := false // whether the tag says if the field is required
+	for _, entry := range splitSchemaTag(tag) {
+		key, value, _ := strings.Cut(entry, "=")
+		var err error
+		switch key {
+		case "":
+			continue
+		case "required":
+			*required = true
+			explicit = true
+		case "optional":
+			*required = false
+			explicit = true
+		case "description", "title", "format", "pattern":
+			schema[key] = value
+		case "enum":
+			var v interface{}
+			if v, err = parseSchemaValue(t, value); err == nil {
+				enum = append(enum, v)
+			}
+		case "default":
+			schema[key], err = parseSchemaValue(t, value)
+		case "minimum", "maximum", "multipleOf":
+			schema[key], err = strconv.ParseFloat(value, 64)
+		case "minLength", "maxLength", "minItems", "maxItems":
+			schema[key], err = strconv.Atoi(value)
+		default:
+			return fmt.Errorf("unknown jsonschema key %q", key)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid jsonschema %s %q: %w", key, value, err)
+		}
+	}
+	if enum != nil {
+		schema["enum"] = enum
+	}
+	// A field with a default is filled in when missing, so it is only
+	// required when the tag says so
+	if _, ok := schema["default"]; ok && !explicit {
+		*required = false
+	}
+	return nil
+}
+
+// splitSchemaTag splits a jsonschema tag at commas not escaped as \,
+func splitSchemaTag(tag string) []string {
+	var entries []string
+	var entry strings.Builder
+	for i := 0; i < len(tag); i++ {
+		switch {
+		case tag[i] == '\\' && i+1 < len(tag) && tag[i+1] == ',':
+			entry.WriteByte(',')
+			i++
+		case tag[i] == ',':
+			entries = append(entries, entry.String())
+			entry.Reset()
+		default:
+			entry.WriteByte(tag[i])
+		}
+	}
+	return append(entries, entry.String())
+}
+
+// parseSchemaValue parses a default or enum value for a field of type t
+func parseSchemaValue(t reflect.Type, value string) (interface{}, error) {
+	switch t.Kind() {
+	case reflect.Bool:
+		return strconv.ParseBool(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return strconv.ParseFloat(value, 64)
+	}
+	return value, nil
+}
@@ -0,0 +1,257 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/shaneholloman/mcp-server-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type forecastArgs struct {
+	City  string   `json:"city" jsonschema:"description=Name of the city\\, in English,minLength=2"`
+	Units string   `json:"units,omitempty" jsonschema:"enum=metric,enum=imperial,default=metric"`
+	Days  int      `json:"days,omitempty" jsonschema:"minimum=1,maximum=7,default=1"`
+	Tags  []string `json:"tags,omitempty"`
+	Debug bool     `json:"-"`
+	forecastWindow
+}
+
+type forecastWindow struct {
+	Start *time.Time `json:"start"`
+}
+
+type forecast struct {
+	City  string  `json:"city"`
+	Days  int     `json:"days"`
+	Units string  `json:"units"`
+	High  float64 `json:"high"`
+}
+
+func TestNewTypedTool_Schema(t *testing.T) {
+	tool := NewTypedTool(
+		"forecast",
+		func(ctx context.Context, args forecastArgs) (forecast, error) {
+			return forecast{}, nil
+		},
+		mcp.WithDescription("Get the weather forecast"),
+	)
+
+	assert.Equal(t, "forecast", tool.Tool.Name)
+	assert.Equal(t, "Get the weather forecast", tool.Tool.Description)
+	assert.Equal(t, mcp.ToolInputSchema{
+		Type: "object",
+		Properties: map[string]interface{}{
+			"city": map[string]interface{}{
+				"type":        "string",
+				"description": "Name of the city, in English",
+				"minLength":   2,
+			},
+			"units": map[string]interface{}{
+				"type":    "string",
+				"enum":    []interface{}{"metric", "imperial"},
+				"default": "metric",
+			},
+			"days": map[string]interface{}{
+				"type":    "integer",
+				"minimum": float64(1),
+				"maximum": float64(7),
+				"default": float64(1),
+			},
+			"tags": map[string]interface{}{
+				"type":  "array",
+				"items": map[string]interface{}{"type": "string"},
+			},
+			"start": map[string]interface{}{
+				"type":   "string",
+				"format": "date-time",
+			},
+		},
+		Required: []string{"city"},
+	}, tool.Tool.InputSchema)
+}
+
+func TestNewTypedTool_DefaultsAreOptional(t *testing.T) {
+	tool := NewTypedTool(
+		"search",
+		func(ctx context.Context, args struct {
+			Query string `json:"query"`
+			Limit int    `json:"limit" jsonschema:"default=10"`
+			Page  int    `json:"page" jsonschema:"default=1,required"`
+		}) (string, error) {
+			return "", nil
+		},
+	)
+	assert.Equal(t, []string{"query", "page"}, tool.Tool.InputSchema.Required)
+}
+
+func TestAddTypedTool_RegistrationOptions(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0")
+	AddTypedTool(
+		server,
+		mcp.NewTool("slow", mcp.WithDescription("Takes its time")),
+		func(ctx context.Context, args struct{}) (string, error) {
+			<-ctx.Done()
+			return "", ctx.Err()
+		},
+		ToolTimeout(10*time.Millisecond),
+	)
+
+	require.Contains(t, server.tools, "slow")
+	assert.Equal(t, "Takes its time", server.tools["slow"].tool.Description)
+
+	result := callTool(t, server, 1, "slow")
+	assert.True(t, result.IsError)
+	assert.Equal(t, "Tool slow timed out after 10ms", resultText(t, result))
+}
+
+func TestNewTypedTool_InvalidArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		newTool func()
+	}{
+		{
+			name: "Not a struct",
+			newTool: func() {
+				NewTypedTool("bad", func(ctx context.Context, args string) (string, error) {
+					return "", nil
+				})
+			},
+		},
+		{
+			name: "Unknown tag key",
+			newTool: func() {
+				type args struct {
+					Name string `jsonschema:"colour=red"`
+				}
+				NewTypedTool("bad", func(ctx context.Context, args args) (string, error) {
+					return "", nil
+				})
+			},
+		},
+		{
+			name: "Invalid tag value",
+			newTool: func() {
+				type args struct {
+					Count int `jsonschema:"maximum=many"`
+				}
+				NewTypedTool("bad", func(ctx context.Context, args args) (string, error) {
+					return "", nil
+				})
+			},
+		},
+		{
+			name: "Unsupported type",
+			newTool: func() {
+				type args struct {
+					Callback func()
+				}
+				NewTypedTool("bad", func(ctx context.Context, args args) (string, error) {
+					return "", nil
+				})
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Panics(t, tt.newTool)
+		})
+	}
+}
+
+func TestAddTypedTool(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0")
+	AddTypedTool(server, mcp.NewTool("forecast"), func(ctx context.Context, args forecastArgs) (forecast, error) {
+		if args.City == "Atlantis" {
+			return forecast{}, fmt.Errorf("no weather data for %s", args.City)
+		}
+		if args.City == "Nowhere" {
+			return forecast{}, mcp.NewError(mcp.INVALID_PARAMS, "unknown city", nil)
+		}
+		return forecast{City: args.City, Days: args.Days, Units: args.Units, High: 21.5}, nil
+	})
+	AddTypedTool(server, mcp.NewTool("greet"), func(ctx context.Context, args struct {
+		Name     string `json:"name"`
+		Greeting string `json:"greeting" jsonschema:"default=Hello"`
+	}) (string, error) {
+		return args.Greeting + ", " + args.Name, nil
+	})
+
+	tests := []struct {
+		name          string
+		tool          string
+		arguments     map[string]interface{}
+		expectedText  string
+		expectedError bool
+	}{
+		{
+			name:         "Decoded arguments with defaults",
+			tool:         "forecast",
+			arguments:    map[string]interface{}{"city": "Oslo"},
+			expectedText: `{"city":"Oslo","days":1,"units":"metric","high":21.5}`,
+		},
+		{
+			name:         "String result with a default",
+			tool:         "greet",
+			arguments:    map[string]interface{}{"name": "Ada"},
+			expectedText: "Hello, Ada",
+		},
+		{
+			name:         "Default overridden",
+			tool:         "greet",
+			arguments:    map[string]interface{}{"name": "Ada", "greeting": "Hi"},
+			expectedText: "Hi, Ada",
+		},
+		{
+			name:          "Schema violations",
+			tool:          "forecast",
+			arguments:     map[string]interface{}{"days": 9},
+			expectedText:  "Invalid arguments for tool forecast:\n- city: is required\n- days: must be at most 7",
+			expectedError: true,
+		},
+		{
+			name:          "Wrong type",
+			tool:          "forecast",
+			arguments:     map[string]interface{}{"city": "Oslo", "days": 1.5},
+			expectedText:  "Invalid arguments for tool forecast:\n- days: expected integer, got number",
+			expectedError: true,
+		},
+		{
+			name:          "Handler error",
+			tool:          "forecast",
+			arguments:     map[string]interface{}{"city": "Atlantis"},
+			expectedText:  "no weather data for Atlantis",
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := callToolWithArguments(t, server, tt.tool, tt.arguments)
+			assert.Equal(t, tt.expectedError, result.IsError)
+			require.Len(t, result.Content, 1)
+			assert.Equal(t, tt.expectedText, result.Content[0].(mcp.TextContent).Text)
+		})
+	}
+
+	t.Run("mcp.Error is sent as a JSON-RPC error", func(t *testing.T) {
+		message := fmt.Sprintf(
+			`{"jsonrpc": "2.0", "id": 1, "method": "tools/call", "params": {"name": "forecast", "arguments": %s}}`,
+			mustMarshal(t, map[string]interface{}{"city": "Nowhere"}),
+		)
+		response := server.HandleMessage(context.Background(), []byte(message))
+		errorResponse, ok := response.(mcp.JSONRPCError)
+		require.True(t, ok)
+		assert.Equal(t, mcp.INVALID_PARAMS, errorResponse.Error.Code)
+	})
+}
+
+func TestDecodeArguments(t *testing.T) {
+	var args forecastArgs
+	err := decodeArguments(map[string]interface{}{"city": 42}, &args)
+	assert.EqualError(t, err, "city: expected string, got number")
+}
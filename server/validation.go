@@ -0,0 +1,414 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/shaneholloman/mcp-server-go/mcp"
+)
+
+// WithToolArgumentValidation validates the arguments of every tool call
+// against the tool's InputSchema before its handler runs. Calls with invalid
+// arguments get a CallToolResult with IsError set that lists every
+// violation, and defaults declared in the schema are filled in for missing
+// properties. Individual tools can opt in or out with ValidateToolArguments.
+func WithToolArgumentValidation() ServerOption {
+	return func(s *MCPServer) {
+		s.validateToolArguments = true
+	}
+}
+
+// ValidateToolArguments enables or disables argument validation for a single
+// tool, overriding WithToolArgumentValidation
+func ValidateToolArguments(enabled bool) ToolRegistrationOption {
+	return func(r *toolRegistration) {
+		r.validate = &enabled
+	}
+}
+
+// validateArguments returns a handler that checks the call's arguments
+// against the schema of tool and applies its defaults before calling next
+func validateArguments(tool mcp.Tool, next ToolHandlerFunc) ToolHandlerFunc {
+	schema := map[string]interface{}{
+		"type":       tool.InputSchema.Type,
+		"properties": tool.InputSchema.Properties,
+		"required":   tool.InputSchema.Required,
+	}
+	validator := &argumentValidator{patterns: map[string]*regexp.Regexp{}}
+
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		arguments := request.Params.Arguments
+		if arguments == nil {
+			arguments = map[string]interface{}{}
+		}
+		value, violations := validator.validate(schema, arguments, "")
+		if len(violations) > 0 {
+			return mcp.NewToolResultError(fmt.Sprintf(
+				"Invalid arguments for tool %s:\n- %s",
+				tool.Name,
+				strings.Join(violations, "\n- "),
+			)), nil
+		}
+		request.Params.Arguments = value.(map[string]interface{})
+		return next(ctx, request)
+	}
+}
+
+// argumentValidator checks values against JSON Schemas built with the
+// mcp.Tool options, caching the regular expressions it compiles
+type argumentValidator struct {
+	mu       sync.Mutex
+	patterns map[string]*regexp.Regexp
+}
+
+// validate checks value against schema and returns it with defaults applied
+// to any objects it contains, along with a description of each violation.
+// Objects are copied rather than modified in place.
+func (v *argumentValidator) validate(
+	schema map[string]interface{},
+	value interface{},
+	path string,
+) (interface{}, []string) {
+	if schemaType, ok := schema["type"].(string); ok && schemaType != "" {
+		if !hasType(value, schemaType) {
+			return value, []string{fmt.Sprintf(
+				"%s: expected %s, got %s",
+				displayPath(path),
+				schemaType,
+				typeName(value),
+			)}
+		}
+	}
+
+	var violations []string
+	if enum, ok := enumValues(schema["enum"]); ok && !containsValue(enum, value) {
+		violations = append(violations, fmt.Sprintf(
+			"%s: must be one of %s",
+			displayPath(path),
+			formatEnum(enum),
+		))
+	}
+
+	switch value := value.(type) {
+	case string:
+		violations = append(violations, v.validateString(schema, value, path)...)
+	case map[string]interface{}:
+		object, objectViolations := v.validateObject(schema, value, path)
+		return object, append(violations, objectViolations...)
+	case []interface{}:
+		array, arrayViolations := v.validateArray(schema, value, path)
+		return array, append(violations, arrayViolations...)
+	default:
+		if number, ok := toFloat(value); ok {
+			violations = append(violations, validateNumber(schema, number, path)...)
+		}
+	}
+	return value, violations
+}
+
+func (v *argumentValidator) validateString(
+	schema map[string]interface{},
+	value string,
+	path string,
+) []string {
+	var violations []string
+	length := utf8.RuneCountInString(value)
+	if min, ok := toFloat(schema["minLength"]); ok && float64(length) < min {
+		violations = append(violations, fmt.Sprintf(
+			"%s: must be at least %v characters long",
+			displayPath(path),
+			min,
+		))
+	}
+	if max, ok := toFloat(schema["maxLength"]); ok && float64(length) > max {
+		violations = append(violations, fmt.Sprintf(
+			"%s: must be at most %v characters long",
+			displayPath(path),
+			max,
+		))
+	}
+	if pattern, ok := schema["pattern"].(string); ok {
+		re, err := v.compile(pattern)
+		if err != nil {
+			violations = append(violations, fmt.Sprintf(
+				"%s: schema pattern %q is invalid: %v",
+				displayPath(path),
+				pattern,
+				err,
+			))
+		} else if !re.MatchString(value) {
+			violations = append(violations, fmt.Sprintf(
+				"%s: must match pattern %q",
+				displayPath(path),
+				pattern,
+			))
+		}
+	}
+	return violations
+}
+
+func validateNumber(schema map[string]interface{}, value float64, path string) []string {
+	var violations []string
+	if min, ok := toFloat(schema["minimum"]); ok && value < min {
+		violations = append(violations, fmt.Sprintf(
+			"%s: must be at least %v",
+			displayPath(path),
+			min,
+		))
+	}
+	if max, ok := toFloat(schema["maximum"]); ok && value > max {
+		violations = append(violations, fmt.Sprintf(
+			"%s: must be at most %v",
+			displayPath(path),
+			max,
+		))
+	}
+	if multiple, ok := toFloat(schema["multipleOf"]); ok && multiple > 0 {
+		quotient := value / multiple
+		if math.Abs(quotient-math.Round(quotient)) > 1e-9 {
+			violations = append(violations, fmt.Sprintf(
+				"%s: must be a multiple of %v",
+				displayPath(path),
+				multiple,
+			))
+		}
+	}
+	return violations
+}
+
+func (v *argumentValidator) validateObject(
+	schema map[string]interface{},
+	value map[string]interface{},
+	path string,
+) (map[string]interface{}, []string) {
+	properties, _ := schema["properties"].(map[string]interface{})
+
+	var violations []string
+	for _, name := range stringList(schema["required"]) {
+		if _, ok := value[name]; !ok {
+			violations = append(violations, fmt.Sprintf(
+				"%s: is required",
+				joinPath(path, name),
+			))
+		}
+	}
+
+	object := make(map[string]interface{}, len(value))
+	for name, property := range value {
+		object[name] = property
+	}
+
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		propertySchema, ok := properties[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		property, ok := object[name]
+		if !ok {
+			if defaultValue, ok := propertySchema["default"]; ok {
+				object[name] = defaultValue
+			}
+			continue
+		}
+		validated, propertyViolations := v.validate(
+			propertySchema,
+			property,
+			joinPath(path, name),
+		)
+		object[name] = validated
+		violations = append(violations, propertyViolations...)
+	}
+	return object, violations
+}
+
+func (v *argumentValidator) validateArray(
+	schema map[string]interface{},
+	value []interface{},
+	path string,
+) ([]interface{}, []string) {
+	items, ok := schema["items"].(map[string]interface{})
+	if !ok {
+		return value, nil
+	}
+
+	var violations []string
+	array := make([]interface{}, len(value))
+	for i, item := range value {
+		validated, itemViolations := v.validate(
+			items,
+			item,
+			fmt.Sprintf("%s[%d]", path, i),
+		)
+		array[i] = validated
+		violations = append(violations, itemViolations...)
+	}
+	return array, violations
+}
+
+// compile returns the compiled form of pattern, compiling it on first use
+func (v *argumentValidator) compile(pattern string) (*regexp.Regexp, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if re, ok := v.patterns[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	v.patterns[pattern] = re
+	return re, nil
+}
+
+// hasType reports whether value is of the JSON Schema type schemaType
+func hasType(value interface{}, schemaType string) bool {
+	switch schemaType {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := toFloat(value)
+		return ok
+	case "integer":
+		number, ok := toFloat(value)
+		return ok && number == math.Trunc(number)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "null":
+		return value == nil
+	}
+	// Unknown types are not checked
+	return true
+}
+
+// typeName describes the JSON type of value for violation messages
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+	if _, ok := toFloat(value); ok {
+		return "number"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// toFloat converts JSON numbers, and the Go numbers tools may be called with
+// directly, to float64
+func toFloat(value interface{}) (float64, bool) {
+	switch value := value.(type) {
+	case float64:
+		return value, true
+	case float32:
+		return float64(value), true
+	case int:
+		return float64(value), true
+	case int32:
+		return float64(value), true
+	case int64:
+		return float64(value), true
+	}
+	return 0, false
+}
+
+// enumValues returns the allowed values of an enum, which mcp.Enum stores as
+// a []string
+func enumValues(enum interface{}) ([]interface{}, bool) {
+	switch enum := enum.(type) {
+	case []interface{}:
+		return enum, true
+	case []string:
+		values := make([]interface{}, len(enum))
+		for i, value := range enum {
+			values[i] = value
+		}
+		return values, true
+	}
+	return nil, false
+}
+
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, candidate := range values {
+		a, aNumber := toFloat(candidate)
+		b, bNumber := toFloat(value)
+		if aNumber && bNumber && a == b {
+			return true
+		}
+		if reflect.DeepEqual(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func formatEnum(values []interface{}) string {
+	formatted := make([]string, len(values))
+	for i, value := range values {
+		if s, ok := value.(string); ok {
+			formatted[i] = fmt.Sprintf("%q", s)
+		} else {
+			formatted[i] = fmt.Sprintf("%v", value)
+		}
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// stringList returns the names in a required list, which is a []string in
+// tool schemas and a []interface{} in schemas decoded from JSON
+func stringList(list interface{}) []string {
+	switch list := list.(type) {
+	case []string:
+		return list
+	case []interface{}:
+		names := make([]string, 0, len(list))
+		for _, name := range list {
+			if name, ok := name.(string); ok {
+				names = append(names, name)
+			}
+		}
+		return names
+	}
+	return nil
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func displayPath(path string) string {
+	if path == "" {
+		return "arguments"
+	}
+	return path
+}
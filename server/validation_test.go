@@ -0,0 +1,250 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"testing"
+
+	"github.com/shaneholloman/mcp-server-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createValidatingServer(
+	opts ...ServerOption,
+) (*MCPServer, chan map[string]interface{}) {
+	server := NewMCPServer("test-server", "1.0.0", opts...)
+	received := make(chan map[string]interface{}, 1)
+	server.AddTool(
+		mcp.NewTool("forecast",
+			mcp.WithString("city",
+				mcp.Required(),
+				mcp.MinLength(2),
+				mcp.MaxLength(20),
+				mcp.Pattern("^[A-Za-z ]+$"),
+			),
+			mcp.WithString("units",
+				mcp.Enum("metric", "imperial"),
+				mcp.DefaultString("metric"),
+			),
+			mcp.WithNumber("days",
+				mcp.Min(1),
+				mcp.Max(7),
+				mcp.MultipleOf(1),
+			),
+			mcp.WithBoolean("hourly", mcp.DefaultBool(false)),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			received <- request.Params.Arguments
+			return mcp.NewToolResultText("sunny"), nil
+		},
+	)
+	return server, received
+}
+
+func callToolWithArguments(
+	t *testing.T,
+	server *MCPServer,
+	name string,
+	arguments map[string]interface{},
+) *mcp.CallToolResult {
+	t.Helper()
+	message, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params": map[string]interface{}{
+			"name":      name,
+			"arguments": arguments,
+		},
+	})
+	require.NoError(t, err)
+
+	response, ok := server.HandleMessage(context.Background(), message).(mcp.JSONRPCResponse)
+	require.True(t, ok)
+	result, ok := response.Result.(*mcp.CallToolResult)
+	require.True(t, ok)
+	return result
+}
+
+func TestMCPServer_ToolArgumentValidation(t *testing.T) {
+	tests := []struct {
+		name               string
+		arguments          map[string]interface{}
+		expectedArguments  map[string]interface{}
+		expectedViolations []string
+	}{
+		{
+			name:      "Valid arguments with defaults applied",
+			arguments: map[string]interface{}{"city": "Paris", "days": 3},
+			expectedArguments: map[string]interface{}{
+				"city":   "Paris",
+				"days":   float64(3),
+				"units":  "metric",
+				"hourly": false,
+			},
+		},
+		{
+			name: "Explicit values are kept",
+			arguments: map[string]interface{}{
+				"city":   "Oslo",
+				"units":  "imperial",
+				"hourly": true,
+			},
+			expectedArguments: map[string]interface{}{
+				"city":   "Oslo",
+				"units":  "imperial",
+				"hourly": true,
+			},
+		},
+		{
+			name:      "Missing required property",
+			arguments: map[string]interface{}{},
+			expectedViolations: []string{
+				"city: is required",
+			},
+		},
+		{
+			name: "Every violation is reported",
+			arguments: map[string]interface{}{
+				"city":   "P",
+				"units":  "kelvin",
+				"days":   7.5,
+				"hourly": "yes",
+			},
+			expectedViolations: []string{
+				"city: must be at least 2 characters long",
+				"days: must be at most 7",
+				"days: must be a multiple of 1",
+				"hourly: expected boolean, got string",
+				`units: must be one of "metric", "imperial"`,
+			},
+		},
+		{
+			name: "Pattern and length",
+			arguments: map[string]interface{}{
+				"city": "Llanfairpwllgwyngyll-gogery",
+				"days": 0,
+			},
+			expectedViolations: []string{
+				"city: must be at most 20 characters long",
+				`city: must match pattern "^[A-Za-z ]+$"`,
+				"days: must be at least 1",
+			},
+		},
+		{
+			name:      "Wrong type",
+			arguments: map[string]interface{}{"city": 42},
+			expectedViolations: []string{
+				"city: expected string, got number",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, received := createValidatingServer(WithToolArgumentValidation())
+			result := callToolWithArguments(t, server, "forecast", tt.arguments)
+
+			if tt.expectedViolations == nil {
+				assert.False(t, result.IsError)
+				assert.Equal(t, tt.expectedArguments, <-received)
+				return
+			}
+
+			assert.True(t, result.IsError)
+			assert.Empty(t, received)
+			require.Len(t, result.Content, 1)
+			text := result.Content[0].(mcp.TextContent).Text
+			expected := "Invalid arguments for tool forecast:"
+			for _, violation := range tt.expectedViolations {
+				expected += "\n- " + violation
+			}
+			assert.Equal(t, expected, text)
+		})
+	}
+}
+
+func TestMCPServer_ToolArgumentValidationOptIn(t *testing.T) {
+	invalid := map[string]interface{}{"city": 42}
+
+	// Validation is off by default
+	server, received := createValidatingServer()
+	result := callToolWithArguments(t, server, "forecast", invalid)
+	assert.False(t, result.IsError)
+	assert.Equal(t, map[string]interface{}{"city": float64(42)}, <-received)
+
+	// A single tool can opt in
+	server.AddTool(
+		mcp.NewTool("strict", mcp.WithString("city", mcp.Required())),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return mcp.NewToolResultText("ok"), nil
+		},
+		ValidateToolArguments(true),
+	)
+	result = callToolWithArguments(t, server, "strict", invalid)
+	assert.True(t, result.IsError)
+
+	// Or opt out of server wide validation
+	server, received = createValidatingServer(WithToolArgumentValidation())
+	server.AddTool(
+		mcp.NewTool("lenient", mcp.WithString("city", mcp.Required())),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			received <- request.Params.Arguments
+			return mcp.NewToolResultText("ok"), nil
+		},
+		ValidateToolArguments(false),
+	)
+	result = callToolWithArguments(t, server, "lenient", invalid)
+	assert.False(t, result.IsError)
+	<-received
+}
+
+func TestArgumentValidator_NestedSchemas(t *testing.T) {
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"tags": map[string]interface{}{
+				"type":  "array",
+				"items": map[string]interface{}{"type": "string", "minLength": 1},
+			},
+			"address": map[string]interface{}{
+				"type":     "object",
+				"required": []interface{}{"city"},
+				"properties": map[string]interface{}{
+					"city":    map[string]interface{}{"type": "string"},
+					"country": map[string]interface{}{"type": "string", "default": "NO"},
+					"zip":     map[string]interface{}{"type": "integer"},
+				},
+			},
+		},
+	}
+	validator := &argumentValidator{patterns: map[string]*regexp.Regexp{}}
+
+	arguments := map[string]interface{}{
+		"tags":    []interface{}{"a", ""},
+		"address": map[string]interface{}{"zip": 1.5},
+	}
+	_, violations := validator.validate(schema, arguments, "")
+	assert.Equal(t, []string{
+		"address.city: is required",
+		"address.zip: expected integer, got number",
+		"tags[1]: must be at least 1 characters long",
+	}, violations)
+
+	arguments = map[string]interface{}{
+		"address": map[string]interface{}{"city": "Oslo", "zip": float64(150)},
+	}
+	value, violations := validator.validate(schema, arguments, "")
+	assert.Empty(t, violations)
+	assert.Equal(t, map[string]interface{}{
+		"address": map[string]interface{}{
+			"city":    "Oslo",
+			"country": "NO",
+			"zip":     float64(150),
+		},
+	}, value)
+	// The caller's arguments are left untouched
+	assert.NotContains(t, arguments["address"], "country")
+}